@@ -0,0 +1,195 @@
+// assets.go
+// Paquete teggo — Recolección de assets de componentes por render.
+// -----------------------------------------------------------------------------
+// El set compilado es compartido y no guarda estado por render. Para saber qué
+// componentes se usaron, cada componente con assets emite una marca invisible
+// ({{track "Card"}}) y Render, al terminar, recorre la salida: retira las marcas
+// y reemplaza los placeholders ({{styles}}, {{scripts}}, {{head}}) con los assets
+// de los componentes que efectivamente se renderizaron, una sola vez cada uno.
+//
+// Además del CSS con alcance (styles.go), un componente declara assets de
+// documento con <script once> y <head>:
+//
+//	{{tag Tabs}}
+//	<head><link rel="stylesheet" href="/tabs.css"></head>
+//	<script once src="/tabs.js"></script>
+//	<div class="tabs">{{slot}}</div>
+//	{{end}}
+
+package teggo
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// markDelim delimita las marcas de la salida: un carácter de uso privado, que
+// los escapes de html/template dejan intacto aunque la marca quede dentro de un
+// <title> o de un atributo (un NUL se reemplazaría por U+FFFD).
+const (
+	markDelim = "\uE000"
+	assetMark = markDelim + "teggo:"
+)
+
+var (
+	assetMarkPattern = regexp.MustCompile(assetMark + `([^` + markDelim + `]*)` + assetMark)
+	scriptPattern    = regexp.MustCompile(`(?is)<script(\s[^>]*)?>(.*?)</script\s*>`)
+	headPattern      = regexp.MustCompile(`(?is)<head\s*>(.*?)</head\s*>`)
+)
+
+// extractDocumentAssets quita de la fuente de un componente los <script once> y
+// los bloques <head>, y devuelve cada uno como asset estático. Igual que con
+// <style scoped>, los bloques se reemplazan por sus saltos de línea. Los assets
+// se emiten tal cual, por eso no pueden contener acciones GoTpl.
+func extractDocumentAssets(source, component string) (string, []string, []string, error) {
+	var scripts, head []string
+	var err error
+	fail := func(offset int, what string) {
+		if err == nil {
+			err = &posError{pos: offset, component: component, err: fmt.Errorf("%s cannot contain template actions", what)}
+		}
+	}
+
+	source = replaceKeepingLines(source, scriptPattern, func(m []int) bool {
+		attrs := ""
+		if m[2] >= 0 {
+			attrs = source[m[2]:m[3]]
+		}
+		attrs, once := removeAttr(attrs, "once")
+		if !once {
+			return false
+		}
+		tag := "<script" + attrs + ">" + source[m[4]:m[5]] + "</script>"
+		if strings.Contains(tag, "{{") {
+			fail(m[0], "<script once>")
+		}
+		scripts = append(scripts, tag)
+		return true
+	})
+	source = replaceKeepingLines(source, headPattern, func(m []int) bool {
+		content := strings.TrimSpace(source[m[2]:m[3]])
+		if strings.Contains(content, "{{") {
+			fail(m[0], "<head>")
+		}
+		if content != "" {
+			head = append(head, content)
+		}
+		return true
+	})
+	return source, scripts, head, err
+}
+
+// replaceKeepingLines reemplaza por sus saltos de línea las coincidencias que
+// extract acepta, para no mover las líneas del resto del archivo.
+func replaceKeepingLines(source string, pattern *regexp.Regexp, extract func(m []int) bool) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range pattern.FindAllStringSubmatchIndex(source, -1) {
+		if !extract(m) {
+			continue
+		}
+		out.WriteString(source[last:m[0]])
+		out.WriteString(strings.Repeat("\n", strings.Count(source[m[0]:m[1]], "\n")))
+		last = m[1]
+	}
+	out.WriteString(source[last:])
+	return out.String()
+}
+
+// removeAttr quita el atributo booleano key de una lista de atributos HTML y
+// normaliza el resto a « a="1" b» (un espacio entre atributos), de modo que el
+// mismo tag con distinto espaciado se deduplique igual.
+func removeAttr(attrs, key string) (string, bool) {
+	var kept []string
+	found := false
+	for _, m := range attrPattern.FindAllStringSubmatchIndex(attrs, -1) {
+		if m[4] >= 0 && strings.EqualFold(attrs[m[4]:m[5]], key) {
+			found = true
+			continue
+		}
+		kept = append(kept, " "+attrs[m[0]:m[1]])
+	}
+	return strings.Join(kept, ""), found
+}
+
+// assetPlaceholder produce la función de un placeholder ({{styles}}, …). Sin
+// componentes con assets no emite nada y Render no post-procesa la salida.
+func (e *Engine) assetPlaceholder(kind string) func() template.HTML {
+	return func() template.HTML {
+		if !e.assets {
+			return ""
+		}
+		return template.HTML(assetMark + kind + assetMark)
+	}
+}
+
+// injectAssets retira las marcas de uso y completa los placeholders.
+func (e *Engine) injectAssets(out string) string {
+	var used []string
+	seen := make(map[string]struct{})
+	for _, m := range assetMarkPattern.FindAllStringSubmatch(out, -1) {
+		if name, ok := strings.CutPrefix(m[1], "use:"); ok {
+			if _, dup := seen[name]; !dup {
+				seen[name] = struct{}{}
+				used = append(used, name)
+			}
+		}
+	}
+	return assetMarkPattern.ReplaceAllStringFunc(out, func(m string) string {
+		switch assetMarkPattern.FindStringSubmatch(m)[1] {
+		case "styles":
+			return styleTag(e.Styles(used...))
+		case "scripts":
+			return strings.Join(uniqueAssets(e.parser.scripts, used), "\n")
+		case "head":
+			return strings.Join(uniqueAssets(e.parser.head, used), "\n")
+		}
+		return ""
+	})
+}
+
+// Styles retorna el CSS con alcance de los componentes indicados, en ese orden.
+// Sin argumentos retorna el de todos los componentes (ordenados por nombre),
+// útil para servirlo como hoja de estilos estática.
+func (e *Engine) Styles(components ...string) string {
+	if len(components) == 0 {
+		for name := range e.parser.styles {
+			components = append(components, name)
+		}
+		sort.Strings(components)
+	}
+	var parts []string
+	for _, name := range components {
+		if css, ok := e.parser.Styles(name); ok {
+			parts = append(parts, css)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// uniqueAssets junta los assets de los componentes usados, en orden de uso y sin
+// repetir: dos componentes que declaran el mismo <script src> lo emiten una vez.
+func uniqueAssets(byComponent map[string][]string, used []string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, name := range used {
+		for _, asset := range byComponent[name] {
+			if _, dup := seen[asset]; dup {
+				continue
+			}
+			seen[asset] = struct{}{}
+			out = append(out, asset)
+		}
+	}
+	return out
+}
+
+func styleTag(css string) string {
+	if css == "" {
+		return ""
+	}
+	return "<style>\n" + css + "\n</style>"
+}
@@ -0,0 +1,263 @@
+// control.go
+// Paquete teggo — Tags de control de flujo para páginas (<If>, <For>, <Flush/>).
+// -----------------------------------------------------------------------------
+// Transpila tags de control a acciones GoTpl estándar:
+//
+//	<If cond=".IsAdmin">A<ElseIf cond=".IsUser">B</ElseIf><Else>C</Else></If>
+//	  -> {{if .IsAdmin}}A{{else if .IsUser}}B{{else}}C{{end}}
+//
+//	<For each=".Users" as="user" index="i">…<Empty>Sin usuarios</Empty></For>
+//	  -> {{range $i, $user := .Users}}…{{else}}Sin usuarios{{end}}
+//
+//	<Flush/>  -> {{flush}} (punto de envío al cliente en RenderStream; no se
+//	  admite dentro de slots ni en el cuerpo de páginas con layout)
+//
+//	<Fragment name="users">…</Fragment>
+//	  -> {{template "pages.Home#users" .}} (ver Engine.RenderFragment)
+
+package teggo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// controlTags son los tags reservados de control de flujo.
+var controlTags = map[string]struct{}{
+	"If":       {},
+	"ElseIf":   {},
+	"Else":     {},
+	"For":      {},
+	"Empty":    {},
+	"Flush":    {},
+	"Fragment": {},
+}
+
+var varNamePattern = regexp.MustCompile(`^\$?([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// flushAction reconoce {{flush}} escrito a mano.
+var flushAction = regexp.MustCompile(`^{{-?\s*flush\s*-?}}$`)
+
+// errFlushInSlot: el contenido de un slot se ejecuta en memoria y se imprime
+// completo, así que un flush ahí no enviaría nada antes de tiempo. Va en la
+// página o en el layout, fuera de los slots.
+var errFlushInSlot = errors.New("<Flush/> is not allowed inside a slot (slot content is buffered); move it to the page or the layout")
+
+// renderControl despacha un tag de control marcado.
+func (w *pageWalker) renderControl(buf *bytes.Buffer, n *node, tag string, attrs []componentAttr) error {
+	switch tag {
+	case "If":
+		return w.renderIf(buf, n, attrs)
+	case "For":
+		return w.renderFor(buf, n, attrs)
+	case "Flush":
+		if w.slots > 0 {
+			return errFlushInSlot
+		}
+		// <Flush/> no tiene contenido; si se escribió <Flush></Flush>, los hijos
+		// se emiten a continuación.
+		buf.WriteString("{{flush}}")
+		return w.walkChildren(buf, n)
+	case "Fragment":
+		return w.renderFragment(buf, n, attrs)
+	}
+	return fmt.Errorf("<%s> must be a direct child of <%s>", tag, parentControl(tag))
+}
+
+// renderIf genera {{if}} y sus ramas <ElseIf>/<Else> (hijos directos de <If>).
+func (w *pageWalker) renderIf(buf *bytes.Buffer, n *node, attrs []componentAttr) error {
+	cond, err := requiredAttr("If", attrs, "cond")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "{{if %s}}", cond)
+	// Lo declarado dentro de las ramas vale hasta el {{end}}
+	vars, scopes := slices.Clip(w.vars), slices.Clip(w.scopes)
+	defer func() { w.vars, w.scopes = vars, scopes }()
+
+	seenElse := false
+	for _, c := range n.Children {
+		tag := controlOf(c)
+		branchAttrs := c.Attrs
+		if tag != "" {
+			buf.WriteString(w.mark(c.Pos, tag))
+		}
+		switch tag {
+		case "ElseIf":
+			if seenElse {
+				return fmt.Errorf("<ElseIf> after <Else>")
+			}
+			branchCond, err := requiredAttr("ElseIf", branchAttrs, "cond")
+			if err != nil {
+				return &posError{pos: c.Pos, component: tag, err: err}
+			}
+			fmt.Fprintf(buf, "{{else if %s}}", branchCond)
+		case "Else":
+			if seenElse {
+				return fmt.Errorf("duplicated <Else>")
+			}
+			seenElse = true
+			buf.WriteString("{{else}}")
+		default:
+			if err := w.walkNode(buf, c); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.walkChildren(buf, c); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString("{{end}}")
+	return nil
+}
+
+// renderFor genera {{range}}; each es la colección, as/index nombran variables
+// y un hijo <Empty> se convierte en la rama {{else}}.
+func (w *pageWalker) renderFor(buf *bytes.Buffer, n *node, attrs []componentAttr) error {
+	each, err := requiredAttr("For", attrs, "each")
+	if err != nil {
+		return err
+	}
+	as, err := varAttr(attrs, "as")
+	if err != nil {
+		return err
+	}
+	index, err := varAttr(attrs, "index")
+	if err != nil {
+		return err
+	}
+
+	var declared []string
+	switch {
+	case index != "" && as != "":
+		fmt.Fprintf(buf, "{{range $%s, $%s := %s}}", index, as, each)
+		declared = []string{index, as}
+	case index != "":
+		fmt.Fprintf(buf, "{{range $%s, $_ := %s}}", index, each)
+		declared = []string{index}
+	case as != "":
+		fmt.Fprintf(buf, "{{range $%s := %s}}", as, each)
+		declared = []string{as}
+	default:
+		fmt.Fprintf(buf, "{{range %s}}", each)
+	}
+
+	scope := len(w.vars)
+	w.vars = append(w.vars, declared...)
+	w.loops++
+
+	var empty *node
+	for _, c := range n.Children {
+		if controlOf(c) == "Empty" {
+			if empty != nil {
+				return fmt.Errorf("duplicated <Empty>")
+			}
+			empty = c
+			continue
+		}
+		if err := w.walkNode(buf, c); err != nil {
+			return err
+		}
+	}
+	w.vars = w.vars[:scope]
+	w.loops--
+
+	if empty != nil {
+		buf.WriteString("{{else}}")
+		if err := w.walkChildren(buf, empty); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("{{end}}")
+	return nil
+}
+
+// renderFragment mueve el contenido a un define propio de la página, que
+// RenderFragment puede ejecutar solo con los datos de la página. Por eso no
+// puede estar dentro de un <For>, donde el contexto es cada elemento.
+func (w *pageWalker) renderFragment(buf *bytes.Buffer, n *node, attrs []componentAttr) error {
+	name, err := requiredAttr("Fragment", attrs, "name")
+	if err != nil {
+		return err
+	}
+	if w.loops > 0 {
+		return fmt.Errorf("<Fragment name=%q> cannot be inside <For>", name)
+	}
+	if w.fragments == nil {
+		w.fragments = make(map[string]struct{})
+	}
+	if _, dup := w.fragments[name]; dup {
+		return fmt.Errorf("duplicated <Fragment name=%q>", name)
+	}
+	w.fragments[name] = struct{}{}
+
+	// El fragmento es un define propio (también se ejecuta solo, con los datos
+	// de la página): no ve las variables de afuera.
+	vars, scopes := w.vars, w.scopes
+	w.vars, w.scopes = nil, nil
+	var content bytes.Buffer
+	err = w.walkChildren(&content, n)
+	w.vars, w.scopes = vars, scopes
+	if err != nil {
+		return err
+	}
+	define := fragmentName(w.logicalPath, name)
+	w.slotDefs = append(w.slotDefs, fmt.Sprintf(`{{define %q}}%s{{end}}`, define, content.String()))
+	fmt.Fprintf(buf, `{{template %q .}}`, define)
+	return nil
+}
+
+// fragmentName es el nombre del define de un fragmento de página.
+func fragmentName(page, fragment string) string {
+	return page + "#" + fragment
+}
+
+// controlOf devuelve el tag de control de un nodo, o "" si no lo es.
+func controlOf(n *node) string {
+	if n.Kind != tagNode || !isControlTag(n.Name) {
+		return ""
+	}
+	return n.Name
+}
+
+func isControlTag(name string) bool {
+	_, ok := controlTags[name]
+	return ok
+}
+
+func parentControl(tag string) string {
+	if tag == "Empty" {
+		return "For"
+	}
+	return "If"
+}
+
+func requiredAttr(tag string, attrs []componentAttr, key string) (string, error) {
+	for _, a := range attrs {
+		if !a.Spread && a.Key == key && strings.TrimSpace(a.Val) != "" {
+			return strings.TrimSpace(a.Val), nil
+		}
+	}
+	return "", fmt.Errorf("<%s> requires attribute %q", tag, key)
+}
+
+// varAttr lee un nombre de variable opcional («user» o «$user»).
+func varAttr(attrs []componentAttr, key string) (string, error) {
+	for _, a := range attrs {
+		if a.Spread || a.Key != key {
+			continue
+		}
+		m := varNamePattern.FindStringSubmatch(strings.TrimSpace(a.Val))
+		if m == nil {
+			return "", fmt.Errorf("invalid variable name %q in <For %s>", a.Val, key)
+		}
+		return m[1], nil
+	}
+	return "", nil
+}
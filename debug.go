@@ -6,28 +6,33 @@
 package teggo
 
 import (
+	"errors"
 	"fmt"
-	"html/template"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 // DebugParseTemplates compila cada archivo individualmente y muestra errores tempranos.
-// Si debug está activo, imprime confirmación en consola.
+// Los nombres lógicos se resuelven igual que al construir el engine (raíces y
+// función de nombres de sus Options). Los errores se devuelven como
+// *CompileError, ubicados en el archivo original. Si debug está activo,
+// imprime confirmación en consola.
+//
+// Usa un Parser descartable con los componentes del engine: el engine en uso
+// no se modifica y puede seguir renderizando mientras tanto.
 func (e *Engine) DebugParseTemplates(paths []string) error {
-	rootDir := commonDir(paths)
+	p := e.parser.registryCopy()
 	for _, absPath := range paths {
-		src, _ := os.ReadFile(absPath)
-		rel, _ := filepath.Rel(rootDir, absPath)
-		logical := strings.TrimSuffix(rel, filepath.Ext(rel))
-		mainName := strings.ReplaceAll(logical, string(os.PathSeparator), ".")
-		base := strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath))
-		conv := ParseTagsToGoTpl(string(src), base, mainName)
-
-		_, err := template.New(filepath.Base(absPath)).Funcs(e.funcMap(nil)).Parse(conv)
+		src, err := os.ReadFile(absPath)
 		if err != nil {
-			printTemplateError(absPath, conv, err)
+			return fmt.Errorf("reading %s: %w", absPath, err)
+		}
+		mainName := e.opts.nameOf(absPath)
+
+		set := e.newSet(filepath.Base(absPath))
+		if err := e.compileFile(p, set, absPath, mainName, string(src)); err != nil {
+			printTemplateError(string(src), err)
 			return err
 		}
 	}
@@ -37,21 +42,25 @@ func (e *Engine) DebugParseTemplates(paths []string) error {
 	return nil
 }
 
-// printTemplateError muestra posición y contexto (±3 líneas).
-func printTemplateError(path, tpl string, err error) {
-	fmt.Printf("\n❌ Error en %s\n%v\n", path, err)
-	type liner interface{ Line() int }
-	if e, ok := err.(liner); ok {
-		lines := strings.Split(tpl, "\n")
-		ln := e.Line() - 1
-		start := max(0, ln-2)
-		end := min(len(lines), ln+3)
-		for i := start; i < end; i++ {
-			prefix := "   "
-			if i == ln {
-				prefix = " ▶ "
-			}
-			fmt.Printf("%s%3d | %s\n", prefix, i+1, lines[i])
+// printTemplateError muestra posición y contexto (±2 líneas) del archivo original.
+func printTemplateError(src string, err error) {
+	fmt.Printf("\n❌ %v\n", err)
+	var ce *CompileError
+	if !errors.As(err, &ce) || ce.Line == 0 {
+		return
+	}
+	lines := strings.Split(src, "\n")
+	ln := ce.Line - 1
+	start := max(0, ln-2)
+	end := min(len(lines), ln+3)
+	for i := start; i < end; i++ {
+		prefix := "   "
+		if i == ln {
+			prefix = " ▶ "
+		}
+		fmt.Printf("%s%3d | %s\n", prefix, i+1, lines[i])
+		if i == ln && ce.Column > 0 {
+			fmt.Printf("       | %s^\n", strings.Repeat(" ", ce.Column-1))
 		}
 	}
 }
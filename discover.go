@@ -1,12 +1,14 @@
 // discover.go
 // Paquete teggo — Descubrimiento de archivos de plantilla por patrón.
 // -----------------------------------------------------------------------------
-// Devuelve todos los archivos coincidentes con los patrones glob dados.
+// Devuelve todos los archivos coincidentes con los patrones glob dados, ya sea
+// en el sistema de archivos del SO o en un fs.FS (por ejemplo, un embed.FS).
 
 package teggo
 
 import (
 	"io/fs"
+	"path"
 	"path/filepath"
 	"sort"
 )
@@ -14,27 +16,50 @@ import (
 // Discover recorre dirRoot y devuelve archivos cuyo nombre coincida
 // con los sufijos dados (*.html, *.gotpl, etc.). Si no hay sufijos, trae todo.
 func Discover(dirRoot string, suffixes ...string) []string {
-	if len(suffixes) == 0 {
-		suffixes = []string{"*"}
-	}
-	seen := make(map[string]struct{})
 	var out []string
-	_ = filepath.WalkDir(dirRoot, func(path string, d fs.DirEntry, err error) error {
+	_ = filepath.WalkDir(dirRoot, func(p string, d fs.DirEntry, err error) error {
 		if err != nil || d.IsDir() {
 			return nil
 		}
-		base := filepath.Base(path)
-		for _, pat := range suffixes {
-			if ok, _ := filepath.Match(pat, base); ok {
-				if _, dup := seen[path]; !dup {
-					seen[path] = struct{}{}
-					out = append(out, path)
-				}
-				break
-			}
+		if matchesAny(filepath.Base(p), suffixes) {
+			out = append(out, p)
 		}
 		return nil
 	})
 	sort.Strings(out)
 	return out
 }
+
+// DiscoverFS es Discover sobre un fs.FS: recorre root («.» para todo el FS) y
+// devuelve las rutas del FS (separadas por «/») que coinciden con los sufijos.
+//
+//	//go:embed views
+//	var views embed.FS
+//	paths := teggo.DiscoverFS(views, "views", "*.html")
+func DiscoverFS(fsys fs.FS, root string, suffixes ...string) []string {
+	var out []string
+	_ = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if matchesAny(path.Base(p), suffixes) {
+			out = append(out, p)
+		}
+		return nil
+	})
+	sort.Strings(out)
+	return out
+}
+
+// matchesAny indica si base coincide con algún patrón; sin patrones, todo coincide.
+func matchesAny(base string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, base); ok {
+			return true
+		}
+	}
+	return false
+}
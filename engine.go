@@ -10,170 +10,384 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"log"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"sort"
 	"strings"
-	"unicode"
+	"sync"
 )
 
 // Engine mantiene el set de templates compilado y la bandera de debug.
 type Engine struct {
-	base              *template.Template // Set base, nunca ejecutar ni clonar luego de ejecutar.
-	debug             bool
-	componentRegistry map[string]struct{}
+	base   *template.Template // Set compilado, compartido y de solo lectura: nunca se clona ni se modifica.
+	debug  bool
+	parser *Parser // Transpilador con el registro de componentes de este engine.
+	assets bool    // Algún componente tiene assets: Render post-procesa la salida.
+	flush  bool    // Alguna página usa <Flush/>: Render retira las marcas de flush.
+	frames []Frame // Llamadas a componentes y layouts, por id (ver trace.go).
+	opts   Options // Raíces y nombres lógicos con que se construyó.
 }
 
 // NewEngine compila todos los archivos indicados en paths en un set lógico único.
 // Registra nombres lógicos tipo «pages.Home». Devuelve el engine listo para renderizar.
+// Es un atajo de New(WithPaths(paths...), WithDebug(debug)).
 func NewEngine(paths []string, debug bool) (*Engine, error) {
-	files := make(map[string]string)
-	for _, path := range paths {
-		b, err := os.ReadFile(path)
+	return New(WithPaths(paths...), WithDebug(debug))
+}
+
+// NewEngineFS compila los archivos indicados de fsys (rutas del FS, como las de
+// DiscoverFS). Los nombres lógicos son relativos a la raíz del FS: con
+// «views/pages/Home.html» el nombre es «views.pages.Home»; usa fs.Sub (o
+// Options.Roots) para tomar «views» como raíz.
+func NewEngineFS(fsys fs.FS, paths []string, debug bool) (*Engine, error) {
+	opts := Options{Debug: debug}
+	files := make([]sourceFile, 0, len(paths))
+	for _, p := range paths {
+		b, err := fs.ReadFile(fsys, p)
 		if err != nil {
-			return nil, fmt.Errorf("reading %s: %w", path, err)
+			return nil, fmt.Errorf("reading %s: %w", p, err)
 		}
-		files[path] = string(b)
+		files = append(files, sourceFile{Path: p, Name: opts.name(Root{}, p), Content: string(b)})
 	}
-	return NewEngineFromSource(files, debug)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return newEngine(opts, files)
 }
 
 // NewEngineFromSource permite crear un Engine a partir de archivos ya cargados en memoria.
+// Los nombres lógicos se derivan de la ruta completa con DefaultNaming.
 func NewEngineFromSource(files map[string]string, debug bool) (*Engine, error) {
-	e := &Engine{debug: debug}
+	return New(WithFiles(files), WithDebug(debug))
+}
+
+// newEngine compila files (con sus nombres lógicos ya resueltos) en un set único.
+func newEngine(opts Options, files []sourceFile) (*Engine, error) {
+	e := &Engine{debug: opts.Debug, parser: NewParser(), opts: opts}
+	if err := e.checkFuncs(); err != nil {
+		return nil, err
+	}
 
 	// 1️⃣ REGISTRO DE COMPONENTES
-	e.componentRegistry = make(map[string]struct{})
-	e.registerComponent("slot")
-	for path, content := range files {
-		rel := strings.TrimSuffix(path, filepath.Ext(path))
-		logicalName := strings.ReplaceAll(rel, string(os.PathSeparator), ".")
-		// base := filepath.Base(rel)
-
-		if hasTagDirective(content) {
-			tagName := getTagName(content)
+	byName := make(map[string]sourceFile, len(files))
+	byTag := make(map[string]sourceFile)
+	for _, f := range files {
+		if prev, dup := byName[f.Name]; dup {
+			return nil, &CompileError{File: f.Path, Component: f.Name, Err: fmt.Errorf("logical name %q already used by %s", f.Name, prev.Path)}
+		}
+		byName[f.Name] = f
+
+		if hasTagDirective(f.Content) {
+			tagName := getTagName(f.Content)
+			if isControlTag(tagName) {
+				return nil, tagError(f, tagName, fmt.Errorf("component name %q is reserved for a control tag", tagName))
+			}
+			if prev, dup := byTag[tagName]; dup && tagName != "" {
+				return nil, tagError(f, tagName, fmt.Errorf("component %q already declared in %s", tagName, prev.Path))
+			}
+			byTag[tagName] = f
 			if tagName != "" {
-				e.registerComponent(tagName)
+				defs, err := ParsePropSchema(f.Content)
+				if err != nil {
+					ce := &CompileError{File: f.Path, Component: tagName, Err: err}
+					if loc := propsPattern.FindStringIndex(f.Content); loc != nil {
+						ce.Line, ce.Column = offsetToLineCol(f.Content, loc[0])
+						ce.Snippet = lineText(f.Content, ce.Line)
+					}
+					return nil, ce
+				}
+				e.parser.RegisterComponent(tagName, defs)
 			}
 		} else {
-			e.registerComponent(logicalName)
+			e.parser.RegisterComponent(f.Name, nil)
 		}
 	}
 
-	// Copiar al parser
-	SetComponentRegistry(e.componentRegistry)
-
-	// 2️⃣ PARSEO
-	var sb strings.Builder
-	for path, content := range files {
-		rel := strings.TrimSuffix(path, filepath.Ext(path))
-		logicalName := strings.ReplaceAll(rel, string(os.PathSeparator), ".")
-		base := filepath.Base(rel)
-
-		converted := ParseTagsToGoTpl(content, base, logicalName)
-		sb.WriteString(converted + "\n")
+	// 2️⃣ PARSEO (cada archivo por separado, para ubicar errores)
+	root := e.newSet("root")
+	for _, f := range files {
+		if err := e.compileFile(e.parser, root, f.Path, f.Name, f.Content); err != nil {
+			return nil, err
+		}
+		// Ubica en el original las llamadas registradas al transpilar f.
+		for _, site := range e.parser.sites[len(e.frames):] {
+			line, col := offsetToLineCol(f.Content, site.Pos)
+			e.frames = append(e.frames, Frame{Component: site.Component, Template: site.Page, File: f.Path, Line: line, Column: col})
+		}
 	}
 
-	root := template.New("root")
-	root.Funcs(e.funcMap(root))
-	baseSet, err := root.Parse(sb.String())
-	if err != nil {
+	// 3️⃣ LAYOUTS (existen y no forman ciclos)
+	if err := e.resolveLayouts(root, byName); err != nil {
 		return nil, err
 	}
 
-	e.base = baseSet
+	e.assets = e.parser.anyAssets()
+	e.flush = e.parser.flush
+	e.base = root
 	return e, nil
 }
 
-// Render clona el set y ejecuta el template indicado, seguro para concurrencia.
-func (e *Engine) Render(name string, data any, w io.Writer) error {
-	execSet, err := e.base.Clone()
-	if err != nil {
-		return fmt.Errorf("teggo: unable to clone templates: %w", err)
+// tagError es un CompileError ubicado en la directiva {{tag Name}} de f.
+func tagError(f sourceFile, tagName string, err error) *CompileError {
+	ce := &CompileError{File: f.Path, Component: tagName, Err: err}
+	if loc := tagPattern.FindStringIndex(f.Content); loc != nil {
+		ce.Line, ce.Column = offsetToLineCol(f.Content, loc[0])
+		ce.Snippet = lineText(f.Content, ce.Line)
 	}
-	execSet.Funcs(e.funcMap(execSet))
-	return execSet.ExecuteTemplate(w, name, data)
+	return ce
 }
 
-// TemplateNames retorna la lista de templates lógicos ordenados.
-func (e *Engine) TemplateNames() []string {
-	execSet, err := e.base.Clone()
+// newSet crea un set vacío con las funciones y opciones del engine.
+func (e *Engine) newSet(name string) *template.Template {
+	set := template.New(name)
+	if e.opts.Strict {
+		set.Option("missingkey=error")
+	}
+	return set.Funcs(e.funcMap(set))
+}
+
+// compileFile transpila un archivo y lo parsea dentro de set. Los errores se
+// devuelven como *CompileError ubicados en el archivo original.
+func (e *Engine) compileFile(p *Parser, set *template.Template, path, logicalName, content string) error {
+	component := logicalName
+	if tagName := getTagName(content); tagName != "" {
+		component = tagName
+	}
+	converted, sm, err := p.parse(content, logicalName)
 	if err != nil {
+		return sm.errorAt(path, component, err)
+	}
+	if _, err := set.New(path).Parse(converted); err != nil {
+		return sm.compileError(path, path, component, err)
+	}
+	return nil
+}
+
+// Render ejecuta el template indicado sobre el set compartido, seguro para concurrencia.
+// Los slots viajan como datos (Slot), así que no se clona ni se parsea nada por render.
+// Si hay componentes con assets, la salida se arma en memoria para inyectarlos
+// (ver RenderStream para enviar la salida a medida que se genera). Los errores
+// de ejecución se devuelven como *RenderError con la pila de componentes.
+func (e *Engine) Render(name string, data any, w io.Writer) error {
+	if !e.assets && !e.flush {
+		if err := e.base.ExecuteTemplate(w, name, data); err != nil {
+			return e.renderError(name, err)
+		}
 		return nil
 	}
-	names := make([]string, 0, len(execSet.Templates()))
-	for _, t := range execSet.Templates() {
+	if !e.assets {
+		// Sin assets que inyectar: las marcas de flush se retiran al vuelo.
+		sw := &streamWriter{engine: e, w: w, seen: make(map[string]struct{}), noFlush: true}
+		if err := e.base.ExecuteTemplate(sw, name, data); err != nil {
+			return e.renderError(name, err)
+		}
+		return sw.finish()
+	}
+	var buf bytes.Buffer
+	if err := e.base.ExecuteTemplate(&buf, name, data); err != nil {
+		return e.renderError(name, err)
+	}
+	_, err := io.WriteString(w, e.injectAssets(buf.String()))
+	return err
+}
+
+// RenderFragment ejecuta solo la región <Fragment name="..."> de una página, con
+// los datos de la página. Útil para actualizaciones parciales (htmx, Turbo).
+func (e *Engine) RenderFragment(page, fragment string, data any, w io.Writer) error {
+	name := fragmentName(page, fragment)
+	if e.base.Lookup(name) == nil {
+		return fmt.Errorf("teggo: fragment %q not found in %s", fragment, page)
+	}
+	return e.Render(name, data, w)
+}
+
+// TemplateNames retorna la lista de templates lógicos ordenados.
+func (e *Engine) TemplateNames() []string {
+	templates := e.base.Templates()
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		// Variantes internas que html/template crea al escapar.
+		if strings.Contains(t.Name(), "$htmltemplate") {
+			continue
+		}
 		names = append(names, t.Name())
 	}
 	sort.Strings(names)
 	return names
 }
 
-// FuncMap retorna el mapa de funciones helper para templates, incluyendo partial.
+// Props retorna las props declaradas por un componente registrado.
+func (e *Engine) Props(component string) []PropDef {
+	defs, _ := e.parser.Props(component)
+	return defs
+}
+
+// FuncMap retorna el mapa de funciones helper para templates, incluyendo partial
+// y las funciones propias registradas con WithFuncs.
 func (e *Engine) FuncMap() template.FuncMap {
 	return e.funcMap(e.base)
 }
 
-// funcMap produce el mapa de funciones enlazado al set indicado.
-// Incluye partial seguro (slots), helpers puros, etc.
+// funcMap produce el mapa de funciones enlazado al set indicado: las de Teggo
+// más las propias de Options.Funcs (validadas en checkFuncs).
 func (e *Engine) funcMap(set *template.Template) template.FuncMap {
+	funcs := e.builtinFuncs(set)
+	for name, fn := range e.opts.Funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// builtinFuncs son las funciones de Teggo enlazadas al set indicado.
+// Incluye partial seguro, slot/render (slots diferidos), include, helpers puros, etc.
+func (e *Engine) builtinFuncs(set *template.Template) template.FuncMap {
 	return template.FuncMap{
-		"dict":  Dict,
-		"merge": Merge,
-		"cat":   Cat,
-		"partial": func(name string, props map[string]interface{}) template.HTML {
+		"dict":   Dict,
+		"merge":  Merge,
+		"cat":    Cat,
+		"spread": Spread,
+		"component": func(site int, name string, props map[string]interface{}) (template.HTML, error) {
+			return e.component(set, site, name, props)
+		},
+		"partial": func(name string, props map[string]interface{}) (template.HTML, error) {
 			return e.safePartial(set, name, props)
 		},
+		"props": func(name string, props map[string]interface{}) (map[string]interface{}, error) {
+			defs, _ := e.parser.Props(name)
+			return applyProps(name, defs, props)
+		},
+		"include": func(name string, data interface{}, scope ...interface{}) (template.HTML, error) {
+			return e.include(set, name, data, scope...)
+		},
+		"slot": func(name string, data interface{}, scope ...interface{}) Slot {
+			return func() (template.HTML, error) {
+				return e.include(set, name, data, scope...)
+			}
+		},
+		"render":      RenderSlot,
+		"layoutScope": layoutScope,
+		"track": func(component string) template.HTML {
+			return template.HTML(assetMark + "use:" + component + assetMark)
+		},
+		"styles":  e.assetPlaceholder("styles"),
+		"scripts": e.assetPlaceholder("scripts"),
+		"head":    e.assetPlaceholder("head"),
+		"flush": func() template.HTML {
+			return template.HTML(assetMark + "flush" + assetMark)
+		},
+		"htmlComment": HTMLComment,
 	}
 }
 
-// safePartial ejecuta un componente en aislamiento (slots).
-func (e *Engine) safePartial(_ *template.Template, name string, props map[string]interface{}) template.HTML {
-	var buf bytes.Buffer
+// Slot es contenido diferido que una página pasa a un componente: se ejecuta
+// recién cuando el componente lo imprime ({{slot}} → {{render (index $ "Slot")}}),
+// con el contexto de la página. Un slot que el componente no imprime no cuesta
+// nada.
+type Slot func() (template.HTML, error)
 
-	// Clonar para evitar colisiones de definiciones dinámicas
-	sub, err := e.base.Clone()
-	if err != nil {
-		return e.report(fmt.Errorf("clone error: %w", err))
-	}
-
-	// Inyecta slots: props con clave mayúscula.
-	for k, v := range props {
-		if len(k) > 0 && unicode.IsUpper(rune(k[0])) {
-			if _, perr := sub.New(k).Parse(fmt.Sprint(v)); perr != nil {
-				return e.report(fmt.Errorf("parse slot %q: %w", k, perr))
-			}
+// RenderSlot imprime el valor de un slot: Slot, template.HTML o texto (escapado).
+func RenderSlot(v interface{}) (template.HTML, error) {
+	switch s := v.(type) {
+	case nil:
+		return "", nil
+	case Slot:
+		if s == nil {
+			return "", nil
 		}
+		return s()
+	case func() (template.HTML, error):
+		return s()
+	case template.HTML:
+		return s, nil
+	default:
+		return template.HTML(template.HTMLEscapeString(fmt.Sprint(s))), nil
 	}
+}
 
-	if err := sub.ExecuteTemplate(&buf, name, props); err != nil {
-		return e.report(err)
-	}
-	return template.HTML(buf.String())
+// slotScope transporta hacia el define de un slot el contexto del llamador, la
+// raíz de la página y las variables en alcance en la llamada (<For as="user">,
+// {{$x := …}}), que el define vuelve a declarar —$ incluido— antes de ejecutar
+// su contenido con {{range .Dot}}.
+type slotScope struct {
+	Root interface{}
+	Dot  []interface{}
+	Vars map[string]interface{}
 }
 
-// report imprime el error (debug) y retorna un HTML vacío (producción).
-func (e *Engine) report(err error) template.HTML {
-	log.Printf("Teggo ▶ %v", err)
-	// if e.debug {
-	// 	return template.HTML("<!-- Teggo ERROR: " + template.HTMLEscapeString(err.Error()) + " -->")
-	// }
-	return "" // En producción devuelve vacío
+// include ejecuta un template del set con el contexto del llamador y devuelve
+// el HTML sin espacios alrededor. Lo usan las páginas para pasar slots; scope,
+// si viene, es la raíz de la página seguida de pares nombre/valor de variables
+// en alcance en el punto de llamada.
+func (e *Engine) include(set *template.Template, name string, data interface{}, scope ...interface{}) (template.HTML, error) {
+	if len(scope) > 0 {
+		data = slotScope{Root: scope[0], Dot: []interface{}{data}, Vars: Dict(scope[1:]...)}
+	}
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		bufPool.Put(buf)
+	}()
+	if err := set.ExecuteTemplate(buf, name, data); err != nil {
+		return "", err
+	}
+	return template.HTML(trimOutput(buf.String())), nil
 }
 
-// Registrar un componente
-func (e *Engine) registerComponent(name string) {
-	if e.componentRegistry == nil {
-		e.componentRegistry = map[string]struct{}{}
+// component ejecuta un tag de componente con sus props validadas (y defaults)
+// y devuelve su HTML. Si falla, decide la política de errores (ver report); el
+// error que se propaga lleva site, el id de la llamada, para la pila de RenderError.
+func (e *Engine) component(set *template.Template, site int, name string, props map[string]interface{}) (template.HTML, error) {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		bufPool.Put(buf)
+	}()
+	defs, typed := e.parser.Props(name)
+	var err error
+	if typed {
+		props, err = applyProps(name, defs, props)
+	}
+	if err == nil {
+		err = set.ExecuteTemplate(buf, name, props)
+	}
+	if err == nil {
+		return template.HTML(buf.String()), nil
+	}
+	// Falló un componente anidado que ya pasó por la política: solo se suma a la pila
+	if reported(err) {
+		return "", &frameError{site: site, err: err}
 	}
-	e.componentRegistry[name] = struct{}{}
+	label := "<" + name + ">"
+	if site >= 0 && site < len(e.frames) {
+		label = e.frames[site].String()
+	}
+	if out, ok := e.report(name, label, err); ok {
+		return out, nil
+	}
+	return "", &frameError{site: site, err: err}
 }
 
-// Verificar si existe en el registro
-func (e *Engine) isRegisteredComponent(name string) bool {
-	_, ok := e.componentRegistry[name]
-	return ok
+// bufPool recicla los buffers de los componentes entre llamadas y renders.
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// safePartial ejecuta un componente con las props dadas y devuelve su HTML.
+// Los slots se pasan como valores (Slot o template.HTML) dentro de props. Si
+// falla, la política de errores decide qué se imprime (ver report).
+func (e *Engine) safePartial(set *template.Template, name string, props map[string]interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+
+	defs, _ := e.parser.Props(name)
+	props, err := applyProps(name, defs, props)
+	if err == nil {
+		err = set.ExecuteTemplate(&buf, name, props)
+	}
+	if err == nil {
+		return template.HTML(buf.String()), nil
+	}
+	if reported(err) {
+		return "", err
+	}
+	if out, ok := e.report(name, fmt.Sprintf("partial %q", name), err); ok {
+		return out, nil
+	}
+	return "", &partialError{component: name, err: err}
 }
 
 func getTagName(source string) string {
@@ -0,0 +1,434 @@
+package teggo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewEngineFromSource_ParallelEnginesKeepOwnComponents(t *testing.T) {
+	const n = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Cada engine registra un componente distinto; la página usa ambos nombres
+			// y solo el propio debe transpilarse como componente.
+			own, other := fmt.Sprintf("Admin%d", i), fmt.Sprintf("Public%d", i)
+			if i%2 == 1 {
+				own, other = other, own
+			}
+			eng, err := NewEngineFromSource(map[string]string{
+				"components/Own.html": fmt.Sprintf(`{{tag %s}}<b>%s</b>{{end}}`, own, own),
+				"pages/Home.html":     fmt.Sprintf(`<%s></%s><%s></%s>`, own, own, other, other),
+			}, false)
+			if err != nil {
+				errs <- err
+				return
+			}
+			var out strings.Builder
+			if err := eng.Render("pages.Home", nil, &out); err != nil {
+				errs <- err
+				return
+			}
+			// El tag ajeno no es un componente de este engine: queda tal cual.
+			want := fmt.Sprintf(`<b>%s</b><%s></%s>`, own, other, other)
+			if got := clean(out.String()); got != want {
+				errs <- fmt.Errorf("engine %d: got %q, want %q", i, got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// benchEngine arma una librería de components componentes y una página que
+// invoca uses de ellos (con slot) dentro de un <For>.
+func benchEngine(b testing.TB, components, uses int) *Engine {
+	files := map[string]string{}
+	var page strings.Builder
+	page.WriteString(`<For each=".Items" as="item">`)
+	for i := 0; i < components; i++ {
+		name := fmt.Sprintf("Comp%d", i)
+		files[fmt.Sprintf("components/%s.html", name)] = fmt.Sprintf(
+			`{{tag %s}}{{/* props: Title string!, Slot any */}}<div class="c%d"><h3>{{.Title}}</h3>{{slot}}</div>{{end}}`, name, i)
+		if i < uses {
+			fmt.Fprintf(&page, `<%s Title="t%d"><p>{{$item}}</p></%s>`, name, i, name)
+		}
+	}
+	page.WriteString(`</For>`)
+	files["pages/Home.html"] = page.String()
+
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		b.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	return eng
+}
+
+var benchData = map[string]interface{}{"Items": []string{"a", "b", "c", "d", "e"}}
+
+func TestRender_SharedSetIsReusableAndConcurrent(t *testing.T) {
+	eng := benchEngine(t, 20, 10)
+	var first string
+	var wg sync.WaitGroup
+	outs := make([]string, 8)
+	for i := range outs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var out strings.Builder
+			if err := eng.Render("pages.Home", benchData, &out); err != nil {
+				t.Error(err)
+			}
+			outs[i] = out.String()
+		}(i)
+	}
+	wg.Wait()
+	first = outs[0]
+	if !strings.Contains(first, `<div class="c9"><h3>t9</h3><p>e</p></div>`) {
+		t.Fatalf("unexpected output: %s", first)
+	}
+	for _, o := range outs[1:] {
+		if o != first {
+			t.Fatalf("concurrent renders differ")
+		}
+	}
+	// TemplateNames sigue funcionando después de ejecutar (antes clonaba).
+	if names := eng.TemplateNames(); len(names) == 0 {
+		t.Fatal("TemplateNames returned nothing after render")
+	}
+}
+
+func benchmarkRender(b *testing.B, components, uses int) {
+	eng := benchEngine(b, components, uses)
+	var out strings.Builder
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out.Reset()
+		if err := eng.Render("pages.Home", benchData, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRender_10Components(b *testing.B)   { benchmarkRender(b, 10, 10) }
+func BenchmarkRender_500Components(b *testing.B)  { benchmarkRender(b, 500, 10) }
+func BenchmarkRender_2000Components(b *testing.B) { benchmarkRender(b, 2000, 10) }
+
+func BenchmarkRender_Parallel(b *testing.B) {
+	eng := benchEngine(b, 500, 10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var out strings.Builder
+		for pb.Next() {
+			out.Reset()
+			if err := eng.Render("pages.Home", benchData, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestNewEngineFromSource_CompileErrorsPointAtOriginalSource(t *testing.T) {
+	card := `{{tag Card}}<div>{{slot}}</div>{{end}}`
+	cases := []struct {
+		name      string
+		file      string
+		src       string
+		line, col int
+		component string
+	}{
+		{
+			name: "block moved into a slot define",
+			file: "pages/Home.html",
+			src: `<Card>
+  <p>ok</p>
+  <p>{{ nofunc . }}</p>
+</Card>`,
+			line: 3, col: 6, component: "pages.Home",
+		},
+		{
+			name: "invalid control tag",
+			file: "pages/Home.html",
+			src:  "<p>hola</p>\n  <If>x</If>",
+			line: 2, col: 3, component: "If",
+		},
+		{
+			name: "component named like a control tag",
+			file: "components/Empty.html",
+			src:  "\n{{tag Empty}}<p>vacío</p>{{end}}",
+			line: 2, col: 1, component: "Empty",
+		},
+		{
+			name: "component declared twice",
+			file: "widgets/Card.html",
+			src:  "<!-- otra -->\n{{tag Card}}<p>dup</p>{{end}}",
+			line: 2, col: 1, component: "Card",
+		},
+		{
+			name: "component file",
+			file: "components/Broken.html",
+			src:  "{{tag Broken}}\n<b>\n{{.X | nofunc}}</b>\n{{end}}",
+			line: 3, col: 0, component: "Broken",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{"components/Card.html": card, tc.file: tc.src}
+			_, err := NewEngineFromSource(files, false)
+			var ce *CompileError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected *CompileError, got %T: %v", err, err)
+			}
+			if ce.File != tc.file || ce.Line != tc.line || ce.Column != tc.col || ce.Component != tc.component {
+				t.Errorf("got %s:%d:%d (%s), want %s:%d:%d (%s): %v",
+					ce.File, ce.Line, ce.Column, ce.Component, tc.file, tc.line, tc.col, tc.component, err)
+			}
+			if want := lineText(tc.src, tc.line); ce.Snippet != want {
+				t.Errorf("snippet = %q, want %q", ce.Snippet, want)
+			}
+		})
+	}
+}
+
+func TestRenderFragment_RendersOnlyTheRegion(t *testing.T) {
+	files := map[string]string{
+		"layouts/Main.html":    `<main>{{slot}}</main>`,
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html": `{{layout "layouts.Main"}}
+<h1>{{.Title}}</h1>
+<Card><Fragment name="users"><ul><For each=".Users" as="u"><li>{{$u}}</li></For></ul></Fragment></Card>`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	data := map[string]interface{}{"Title": "Inicio", "Users": []string{"ana", "luis"}}
+
+	var page, frag strings.Builder
+	if err := eng.Render("pages.Home", data, &page); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if err := eng.RenderFragment("pages.Home", "users", data, &frag); err != nil {
+		t.Fatalf("RenderFragment failed: %v", err)
+	}
+	wantFrag := `<ul><li>ana</li><li>luis</li></ul>`
+	if got := clean(frag.String()); got != wantFrag {
+		t.Errorf("fragment: got %q, want %q", got, wantFrag)
+	}
+	if got := clean(page.String()); !strings.Contains(got, `<div class="card">`+wantFrag+`</div>`) {
+		t.Errorf("page should still render the fragment in place: %q", got)
+	}
+	if err := eng.RenderFragment("pages.Home", "missing", data, &frag); err == nil {
+		t.Error("expected an error for an unknown fragment")
+	}
+
+	for _, src := range []string{
+		`<For each=".Users"><Fragment name="row">x</Fragment></For>`,
+		`<Fragment name="a">x</Fragment><Fragment name="a">y</Fragment>`,
+	} {
+		if _, err := NewEngineFromSource(map[string]string{"pages/Bad.html": src}, false); err == nil {
+			t.Errorf("expected a compile error for %s", src)
+		}
+	}
+}
+
+func TestNewEngineFS_LogicalNamesRelativeToFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/components/Card.html": {Data: []byte(`{{tag Card}}<div class="card">{{slot}}</div>{{end}}`)},
+		"views/pages/Home.html":      {Data: []byte(`<Card>{{.Name}}</Card>`)},
+		"views/pages/notes.txt":      {Data: []byte(`ignorar`)},
+	}
+	sub, err := fs.Sub(fsys, "views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := DiscoverFS(sub, ".", "*.html")
+	if want := []string{"components/Card.html", "pages/Home.html"}; strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("DiscoverFS: got %v, want %v", paths, want)
+	}
+
+	eng, err := NewEngineFS(sub, paths, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]string{"Name": "Ana"}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if got := clean(out.String()); got != `<div class="card">Ana</div>` {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestErrorPolicy_PartialFailures(t *testing.T) {
+	files := map[string]string{
+		"components/Box.html": `{{tag Box}}{{/* props: N int! */}}<b>{{.N}}</b>{{end}}`,
+		"pages/Home.html":     `<p>antes</p>{{partial "Box" (dict)}}<p>después</p>`,
+	}
+	logger := &captureLogger{}
+	cases := []struct {
+		policy  ErrorPolicy
+		wantErr bool
+		want    string
+	}{
+		{PolicyLenient, false, `<p>antes</p><p>después</p>`},
+		{PolicyDebug, false, `class="teggo-error"`},
+		{PolicyStrict, true, ``},
+	}
+	for _, tc := range cases {
+		var hooked []string
+		eng, err := New(WithFiles(files), WithLogger(logger), WithErrorPolicy(tc.policy),
+			WithErrorHook(func(component string, err error) { hooked = append(hooked, component) }))
+		if err != nil {
+			t.Fatalf("Engine failed to parse generated templates: %v", err)
+		}
+		var out strings.Builder
+		err = eng.Render("pages.Home", nil, &out)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Render error = %v, want error %v", tc.policy, err, tc.wantErr)
+		}
+		if tc.wantErr && !strings.Contains(fmt.Sprint(err), `partial "Box": <Box>: missing required prop "N"`) {
+			t.Errorf("%s: unexpected error %v", tc.policy, err)
+		}
+		if !strings.Contains(out.String(), tc.want) {
+			t.Errorf("%s: output %q should contain %q", tc.policy, out.String(), tc.want)
+		}
+		if len(hooked) != 1 || hooked[0] != "Box" {
+			t.Errorf("%s: hook calls %v", tc.policy, hooked)
+		}
+	}
+}
+
+func TestErrorPolicy_TagFailures(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"components/Box.html":  `{{tag Box}}{{/* props: N int! */}}<b>{{.N}}</b>{{end}}`,
+		"pages/Home.html":      `<p>antes</p><Card><Box {...$}></Box></Card><p>después</p>`,
+	}
+	logger := &captureLogger{}
+	cases := []struct {
+		policy  ErrorPolicy
+		wantErr bool
+		want    string
+	}{
+		{PolicyLenient, false, `<p>antes</p><div class="card"></div><p>después</p>`},
+		{PolicyDebug, false, `&lt;Box&gt; at pages/Home.html:1:19: &lt;Box&gt;: missing required prop &#34;N&#34;</div></div><p>después</p>`},
+		{PolicyStrict, true, ``},
+	}
+	for _, tc := range cases {
+		var hooked []string
+		eng, err := New(WithFiles(files), WithLogger(logger), WithErrorPolicy(tc.policy),
+			WithErrorHook(func(component string, err error) { hooked = append(hooked, component) }))
+		if err != nil {
+			t.Fatalf("Engine failed to parse generated templates: %v", err)
+		}
+		for mode, render := range map[string]func(io.Writer) error{
+			"Render":       func(w io.Writer) error { return eng.Render("pages.Home", map[string]interface{}{}, w) },
+			"RenderStream": func(w io.Writer) error { return eng.RenderStream("pages.Home", map[string]interface{}{}, w) },
+		} {
+			hooked = nil
+			var out strings.Builder
+			err := render(&out)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("%s/%s: error = %v, want error %v", tc.policy, mode, err, tc.wantErr)
+			}
+			var re *RenderError
+			if tc.wantErr && (!errors.As(err, &re) || len(re.Stack) != 2 || re.Stack[1].Component != "Box") {
+				t.Errorf("%s/%s: expected *RenderError with stack Card > Box, got %v", tc.policy, mode, err)
+			}
+			if !strings.Contains(out.String(), tc.want) {
+				t.Errorf("%s/%s: output %q should contain %q", tc.policy, mode, out.String(), tc.want)
+			}
+			if len(hooked) != 1 || hooked[0] != "Box" {
+				t.Errorf("%s/%s: hook calls %v", tc.policy, mode, hooked)
+			}
+		}
+	}
+}
+
+func TestErrorHook_TagComponentFailures(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html":     `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"components/MyButton.html": `{{tag MyButton}}<button>{{index .Items 3}}</button>{{end}}`,
+		"pages/Home.html":          `<Card><MyButton></MyButton></Card>`,
+	}
+	var hooked []string
+	eng, err := New(WithFiles(files), WithErrorPolicy(PolicyStrict),
+		WithErrorHook(func(component string, err error) { hooked = append(hooked, component) }))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	if err := eng.Render("pages.Home", nil, io.Discard); err == nil {
+		t.Fatal("expected the tag component failure from Render")
+	}
+	if err := eng.RenderStream("pages.Home", nil, io.Discard); err == nil {
+		t.Fatal("expected the tag component failure from RenderStream")
+	}
+	if len(hooked) != 2 || hooked[0] != "MyButton" || hooked[1] != "MyButton" {
+		t.Errorf("expected the hook once per render with the innermost component, got %v", hooked)
+	}
+}
+
+func TestDebugParseTemplates_DoesNotTouchTheLiveEngine(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<style scoped>.c { color: red }</style><script once src="/c.js"></script><div class="c">{{slot}}</div>{{end}}`,
+		"pages/Home.html":      `{{styles}}<Card>hola</Card>{{scripts}}`,
+	}
+	var paths []string
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	eng, err := New(WithDir(dir), WithSuffixes("*.html"))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	sites := len(eng.parser.sites)
+
+	// Con -race: depurar mientras se renderiza no comparte estado con Render.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := eng.DebugParseTemplates(paths); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := eng.Render("pages.Home", nil, io.Discard); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(eng.parser.sites); got != sites || got != len(eng.frames) {
+		t.Errorf("call sites grew: %d -> %d (frames %d)", sites, got, len(eng.frames))
+	}
+}
@@ -0,0 +1,285 @@
+// errors.go
+// Paquete teggo — Errores tipados y mapa de fuente del transpilador.
+// -----------------------------------------------------------------------------
+// El GoTpl generado para una página no conserva las líneas del archivo original
+// (los slots se mueven a defines propios, los tags se reescriben). El walker
+// intercala marcas invisibles con la posición original de cada bloque {{...}} y
+// de cada tag de componente; al final se retiran y quedan como mapa de fuente
+// para traducir los errores de html/template al archivo que escribió el usuario.
+
+package teggo
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CompileError es un error de compilación ubicado en el archivo original.
+type CompileError struct {
+	File      string // Ruta del archivo original.
+	Line      int    // Línea (1-based); 0 si no se conoce.
+	Column    int    // Columna en runas (1-based); 0 si no se conoce.
+	Component string // Componente o template lógico donde ocurrió.
+	Snippet   string // Línea original del error.
+	Err       error
+}
+
+func (e *CompileError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("teggo: ")
+	sb.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&sb, ":%d", e.Column)
+		}
+	}
+	if e.Component != "" {
+		fmt.Fprintf(&sb, ": %s", e.Component)
+	}
+	fmt.Fprintf(&sb, ": %v", e.Err)
+	return sb.String()
+}
+
+func (e *CompileError) Unwrap() error { return e.Err }
+
+// posError es un error del walker en una posición del archivo original.
+type posError struct {
+	pos       int
+	component string
+	err       error
+}
+
+func (e *posError) Error() string { return e.err.Error() }
+func (e *posError) Unwrap() error { return e.err }
+
+// -----------------------------------------------------------------------------
+// Política de errores de render
+// -----------------------------------------------------------------------------
+
+// ErrorPolicy decide qué pasa cuando falla un componente, sea un tag
+// <Componente> o un partial. Los errores de la propia página (o de su layout)
+// siempre salen de Render.
+type ErrorPolicy int
+
+const (
+	// PolicyLenient registra el error y omite el componente (comportamiento por defecto).
+	PolicyLenient ErrorPolicy = iota
+	// PolicyStrict propaga el primer error: Render lo devuelve.
+	PolicyStrict
+	// PolicyDebug registra el error y lo muestra en la página, en un recuadro visible.
+	PolicyDebug
+)
+
+func (p ErrorPolicy) String() string {
+	switch p {
+	case PolicyStrict:
+		return "strict"
+	case PolicyDebug:
+		return "debug"
+	}
+	return "lenient"
+}
+
+// ErrorHook recibe cada error de componente, sea cual sea la política (métricas,
+// alertas). component es el componente que falló (el más interno si estaban
+// anidados) o la página, si el error es de la página.
+type ErrorHook func(component string, err error)
+
+// report aplica la política de errores al fallo de un componente; label nombra
+// la llamada en el log y en el recuadro. Devuelve false con PolicyStrict: el
+// llamador propaga el error.
+func (e *Engine) report(component, label string, err error) (template.HTML, bool) {
+	if e.opts.OnError != nil {
+		e.opts.OnError(component, err)
+	}
+	switch e.opts.ErrorPolicy {
+	case PolicyStrict:
+		return "", false
+	case PolicyDebug:
+		e.opts.logger().Printf("Teggo ▶ %s: %v", label, err)
+		return errorBox(label, err), true
+	}
+	e.opts.logger().Printf("Teggo ▶ %s: %v", label, err)
+	return "", true // En producción devuelve vacío
+}
+
+// reported indica si la cadena de err ya pasó por report: el fallo de un
+// componente anidado hace fallar también a los que lo contienen.
+func reported(err error) bool {
+	var fe *frameError
+	var pe *partialError
+	return errors.As(err, &fe) || errors.As(err, &pe)
+}
+
+// partialError es el fallo de un partial con PolicyStrict; el hook ya lo
+// recibió en report.
+type partialError struct {
+	component string
+	err       error
+}
+
+func (e *partialError) Error() string { return fmt.Sprintf("partial %q: %v", e.component, e.err) }
+func (e *partialError) Unwrap() error { return e.err }
+
+// errorBox es el HTML que reemplaza a un componente roto en PolicyDebug.
+func errorBox(label string, err error) template.HTML {
+	msg := template.HTMLEscapeString(fmt.Sprintf("%s: %v", label, err))
+	return template.HTML(`<!-- Teggo ERROR: ` + strings.ReplaceAll(msg, "--", "&#45;&#45;") + ` -->` +
+		`<div class="teggo-error" style="border:2px solid #c00;background:#fee;color:#900;padding:4px 8px;font:12px monospace;white-space:pre-wrap">` +
+		msg + `</div>`)
+}
+
+// -----------------------------------------------------------------------------
+// Mapa de fuente
+// -----------------------------------------------------------------------------
+
+const sourceMark = "\x00"
+
+var sourceMarkPattern = regexp.MustCompile(sourceMark + `(\d+)` + sourceMark)
+
+// anchor asocia un punto del texto generado con un offset del original.
+type anchor struct {
+	gen   int
+	orig  int
+	label string
+}
+
+// sourceMap traduce posiciones del GoTpl generado al archivo original.
+type sourceMap struct {
+	source     string
+	generated  string
+	anchors    []anchor // ordenados por gen
+	lineOffset int      // sin anchors: líneas agregadas al inicio (identidad desplazada)
+}
+
+// identityMap es el mapa de un archivo cuya conversión no mueve líneas.
+func identityMap(source, generated string, lineOffset int) *sourceMap {
+	return &sourceMap{source: source, generated: generated, lineOffset: lineOffset}
+}
+
+// stripSourceMarks retira las marcas del texto generado y arma el mapa.
+func stripSourceMarks(source, marked string, points []anchor) *sourceMap {
+	sm := &sourceMap{source: source}
+	var out strings.Builder
+	last := 0
+	for _, m := range sourceMarkPattern.FindAllStringSubmatchIndex(marked, -1) {
+		out.WriteString(marked[last:m[0]])
+		last = m[1]
+		idx, _ := strconv.Atoi(marked[m[2]:m[3]])
+		if idx < len(points) {
+			a := points[idx]
+			a.gen = out.Len()
+			sm.anchors = append(sm.anchors, a)
+		}
+	}
+	out.WriteString(marked[last:])
+	sm.generated = out.String()
+	sort.SliceStable(sm.anchors, func(i, j int) bool { return sm.anchors[i].gen < sm.anchors[j].gen })
+	return sm
+}
+
+// locate traduce una línea del texto generado a línea, columna y etiqueta originales.
+func (sm *sourceMap) locate(genLine int) (line, col int, label string) {
+	if sm.anchors == nil {
+		line = genLine - sm.lineOffset
+		if line < 1 {
+			line = 1
+		}
+		return line, 0, ""
+	}
+	start, end := lineBounds(sm.generated, genLine)
+	// Primer anchor de la línea; si no hay, el último anterior.
+	i := sort.Search(len(sm.anchors), func(i int) bool { return sm.anchors[i].gen >= start })
+	var a anchor
+	switch {
+	case i < len(sm.anchors) && sm.anchors[i].gen <= end:
+		a = sm.anchors[i]
+	case i > 0:
+		a = sm.anchors[i-1]
+	default:
+		return 1, 0, ""
+	}
+	line, col = offsetToLineCol(sm.source, a.orig)
+	return line, col, a.label
+}
+
+// compileError construye un CompileError a partir de un error de html/template
+// sobre el texto generado (los mensajes traen «template: NAME:LINE: ...»).
+func (sm *sourceMap) compileError(file, name, component string, err error) *CompileError {
+	ce := &CompileError{File: file, Component: component, Err: err}
+	msg := err.Error()
+	prefix := "template: " + name + ":"
+	if !strings.HasPrefix(msg, prefix) {
+		return ce
+	}
+	rest := msg[len(prefix):]
+	colon := strings.IndexByte(rest, ':')
+	if colon < 0 {
+		return ce
+	}
+	genLine, convErr := strconv.Atoi(rest[:colon])
+	if convErr != nil {
+		return ce
+	}
+	line, col, label := sm.locate(genLine)
+	ce.Line, ce.Column = line, col
+	if label != "" {
+		ce.Component = label
+	}
+	ce.Snippet = lineText(sm.source, line)
+	ce.Err = errors.New(strings.TrimSpace(rest[colon+1:]))
+	return ce
+}
+
+// errorAt convierte un posError del walker en CompileError.
+func (sm *sourceMap) errorAt(file, component string, err error) *CompileError {
+	ce := &CompileError{File: file, Component: component, Err: err}
+	var pe *posError
+	if errors.As(err, &pe) {
+		ce.Line, ce.Column = offsetToLineCol(sm.source, pe.pos)
+		ce.Snippet = lineText(sm.source, ce.Line)
+		if pe.component != "" {
+			ce.Component = pe.component
+		}
+		ce.Err = pe.err
+	}
+	return ce
+}
+
+func offsetToLineCol(src string, off int) (int, int) {
+	if off > len(src) {
+		off = len(src)
+	}
+	before := src[:off]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+func lineBounds(src string, line int) (int, int) {
+	start := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(src[start:], '\n')
+		if next < 0 {
+			return len(src), len(src)
+		}
+		start += next + 1
+	}
+	end := strings.IndexByte(src[start:], '\n')
+	if end < 0 {
+		return start, len(src)
+	}
+	return start, start + end
+}
+
+func lineText(src string, line int) string {
+	start, end := lineBounds(src, line)
+	return src[start:end]
+}
@@ -15,8 +15,12 @@ func main() {
 	// 	fmt.Println(" •", t)
 	// }
 
-	// 2. Inicializa el engine Teggo
-	engine, err := teggo.NewEngine(files, true)
+	// 2. Inicializa el engine Teggo (nombres lógicos relativos a ./examples: «pages.Home»)
+	engine, err := teggo.NewEngineWithOptions(teggo.Options{
+		Roots:    []teggo.Root{{Dir: "./examples"}},
+		Suffixes: []string{"*.html"},
+		Debug:    true,
+	})
 	if err != nil {
 		panic(fmt.Sprintf("Error inicializando Teggo: %v", err))
 	}
@@ -0,0 +1,78 @@
+// funcs.go
+// Paquete teggo — Funciones propias del usuario en el set de templates.
+// -----------------------------------------------------------------------------
+// Las funciones de Options.Funcs se agregan al set antes de parsear, así páginas,
+// componentes, slots y partials pueden usarlas: todos se ejecutan sobre el mismo
+// set compartido. Un nombre que choca con una función de Teggo o de GoTpl es un
+// error al construir el engine (no se pisa en silencio).
+
+package teggo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode"
+)
+
+// goTemplateFuncs son las funciones predefinidas de text/template y html/template.
+var goTemplateFuncs = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print",
+	"printf", "println", "urlquery", "eq", "ge", "gt", "le", "lt", "ne",
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// checkFuncs valida las funciones propias: nombres válidos, sin choques con las
+// predefinidas y con una firma que GoTpl acepte (1 resultado, o 2 con error).
+func (e *Engine) checkFuncs() error {
+	reserved := make(map[string]struct{})
+	for name := range e.builtinFuncs(nil) {
+		reserved[name] = struct{}{}
+	}
+	for _, name := range goTemplateFuncs {
+		reserved[name] = struct{}{}
+	}
+
+	names := make([]string, 0, len(e.opts.Funcs))
+	for name := range e.opts.Funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, clash := reserved[name]; clash {
+			return fmt.Errorf("teggo: func %q conflicts with a built-in function", name)
+		}
+		if !isFuncName(name) {
+			return fmt.Errorf("teggo: func %q is not a valid identifier", name)
+		}
+		if err := checkFuncSignature(e.opts.Funcs[name]); err != nil {
+			return fmt.Errorf("teggo: func %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// isFuncName indica si name es un identificador válido para GoTpl.
+func isFuncName(name string) bool {
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func checkFuncSignature(fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("value of type %T is not a function", fn)
+	}
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("must return one value, or a value and an error")
+	}
+	return nil
+}
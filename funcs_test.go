@@ -0,0 +1,54 @@
+package teggo
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestWithFuncs_AvailableEverywhere(t *testing.T) {
+	files := map[string]string{
+		"layouts/Main.html":    `<main>{{shout "layout"}}{{slot}}</main>`,
+		"components/Card.html": `{{tag Card}}<div>{{shout .Title}}:{{slot}}</div>{{end}}`,
+		"pages/Home.html": `{{layout "layouts.Main"}}
+<For each=".Items" as="item"><Card Title="card">{{shout $item}}</Card></For>{{partial "Card" (dict "Title" "partial")}}`,
+	}
+	eng, err := New(WithFiles(files), WithFuncs(template.FuncMap{
+		"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+	}))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]any{"Items": []string{"a"}}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	want := `<main>LAYOUT!<div>CARD!:A!</div><div>PARTIAL!:</div></main>`
+	if got := clean(out.String()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, ok := eng.FuncMap()["shout"]; !ok {
+		t.Error("FuncMap() should include user funcs")
+	}
+}
+
+func TestWithFuncs_RejectsConflictsAndBadFuncs(t *testing.T) {
+	files := map[string]string{"pages/Home.html": `<p></p>`}
+	for name, fn := range map[string]interface{}{
+		"dict":     func() string { return "" }, // Teggo
+		"len":      func() int { return 0 },     // GoTpl
+		"bad-name": func() string { return "" },
+		"notFunc":  "x",
+		"tooMany":  func() (string, string) { return "", "" },
+	} {
+		_, err := New(WithFiles(files), WithFuncs(template.FuncMap{name: fn}))
+		if err == nil || !strings.Contains(err.Error(), `"`+name+`"`) {
+			t.Errorf("%s: expected a construction error, got %v", name, err)
+		}
+	}
+	ok := template.FuncMap{"withErr": func() (string, error) { return "", errors.New("x") }}
+	if _, err := New(WithFiles(files), WithFuncs(ok)); err != nil {
+		t.Errorf("(value, error) funcs should be accepted: %v", err)
+	}
+}
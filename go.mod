@@ -1,5 +1,3 @@
 module github.com/jad21/teggo
 
 go 1.23.10
-
-require golang.org/x/net v0.41.0
@@ -10,8 +10,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
-	"os"
-	"path/filepath"
+	"reflect"
 	"strings"
 )
 
@@ -42,6 +41,49 @@ func Merge(m1, m2 map[string]interface{}) map[string]interface{} {
 	return res
 }
 
+// Spread convierte un mapa con claves string o un struct (o puntero a struct)
+// en un mapa de props. Los campos de structs embebidos se aplanan; los valores
+// de cualquier otro tipo producen un mapa vacío.
+//
+//	<UserCard {...$user} />  ->  merge (spread $user) (dict ...)
+func Spread(v interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	spreadInto(out, reflect.ValueOf(v))
+	return out
+}
+
+func spreadInto(out map[string]interface{}, rv reflect.Value) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = iter.Value().Interface()
+		}
+	case reflect.Struct:
+		t := rv.Type()
+		// Primero los embebidos, para que los campos propios ganen.
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.Anonymous {
+				spreadInto(out, rv.Field(i))
+			}
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() && !f.Anonymous && rv.Field(i).CanInterface() {
+				out[f.Name] = rv.Field(i).Interface()
+			}
+		}
+	}
+}
+
 // Cat concatena todos los argumentos y los devuelve como template.HTML seguro.
 //
 //	Cat("Hola ", nombre, "!")  -> HTML sin escape adicional.
@@ -53,12 +95,28 @@ func Cat(parts ...interface{}) template.HTML {
 	return template.HTML(b.String())
 }
 
+// HTMLComment devuelve un comentario HTML sin escapar, para que html/template
+// no lo omita. Lo que no sea un único comentario bien formado se escapa.
+//
+//	HTMLComment("<!--[if IE]><p>IE</p><![endif]-->")  -> se emite tal cual
+func HTMLComment(c string) template.HTML {
+	inner, ok := strings.CutPrefix(c, "<!--")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "-->")
+	}
+	if !ok || strings.Contains(inner, "-->") || strings.Contains(inner, "--!>") {
+		return template.HTML(template.HTMLEscapeString(c))
+	}
+	return template.HTML(c)
+}
+
 // BasicFuncMap retorna las funciones puras para uso directo en templates Go.
 func BasicFuncMap() template.FuncMap {
 	return template.FuncMap{
-		"dict":  Dict,
-		"merge": Merge,
-		"cat":   Cat,
+		"dict":   Dict,
+		"merge":  Merge,
+		"cat":    Cat,
+		"spread": Spread,
 	}
 }
 
@@ -75,14 +133,3 @@ func min(a, b int) int {
 	}
 	return b
 }
-
-// commonDir retorna el directorio común para varios paths absolutos.
-func commonDir(paths []string) string {
-	dir := filepath.Dir(paths[0])
-	for _, p := range paths[1:] {
-		for !strings.HasPrefix(p, dir) && dir != string(os.PathSeparator) {
-			dir = filepath.Dir(dir)
-		}
-	}
-	return dir
-}
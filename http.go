@@ -0,0 +1,175 @@
+// http.go
+// Paquete teggo — Integración con net/http.
+// -----------------------------------------------------------------------------
+// Renderer adapta un Engine (o un ReloadingEngine) a net/http: arma la respuesta
+// completa en memoria antes de escribirla, así un error a mitad del render nunca
+// deja HTML parcial en el cliente, y ante un error renderiza una página de error
+// configurable con el código de estado correspondiente.
+//
+//	r := teggo.NewRenderer(engine)
+//	r.ErrorPage = "pages.Error"
+//	http.Handle("/", r.Handler("pages.Home", func(req *http.Request) (any, error) {
+//		user, err := loadUser(req)
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return nil, teggo.NewHTTPError(http.StatusNotFound, err)
+//		}
+//		return map[string]any{"User": user}, err
+//	}))
+
+package teggo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Renderable es lo que Renderer sabe servir: *Engine y *ReloadingEngine.
+type Renderable interface {
+	Render(name string, data any, w io.Writer) error
+}
+
+// DataFunc obtiene los datos de una página a partir de la request. Un error
+// *HTTPError define el código de estado; cualquier otro responde 500.
+type DataFunc func(r *http.Request) (any, error)
+
+// HTTPError es un error con código de estado HTTP.
+type HTTPError struct {
+	Status int
+	Err    error
+}
+
+// NewHTTPError crea un error con código de estado. err puede ser nil.
+func NewHTTPError(status int, err error) *HTTPError {
+	return &HTTPError{Status: status, Err: err}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return fmt.Sprintf("%d %s: %v", e.Status, http.StatusText(e.Status), e.Err)
+}
+
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// ErrorPageData son los datos con los que se renderiza la página de error.
+type ErrorPageData struct {
+	Status     int
+	StatusText string
+	Message    string // Detalle del error con ShowErrors; si no, el texto del estado.
+	Request    *http.Request
+}
+
+// Renderer sirve templates de un Engine por HTTP.
+type Renderer struct {
+	engine      Renderable
+	ContentType string // Por defecto «text/html; charset=utf-8».
+	ErrorPage   string // Template para errores; vacío responde texto plano.
+	ShowErrors  bool   // Muestra el detalle del error (rutas, posiciones) al cliente; solo en desarrollo.
+	Logger      Logger // Por defecto el del engine.
+}
+
+// NewRenderer crea un Renderer para el engine indicado. El detalle de los
+// errores no se muestra al cliente salvo que se active ShowErrors (el Debug del
+// engine solo afecta a la consola).
+func NewRenderer(engine Renderable) *Renderer {
+	r := &Renderer{engine: engine, ContentType: "text/html; charset=utf-8"}
+	switch e := engine.(type) {
+	case *Engine:
+		r.Logger = e.opts.logger()
+	case *ReloadingEngine:
+		r.Logger = e.opts.logger()
+	default:
+		r.Logger = log.Default()
+	}
+	return r
+}
+
+// Handler es un atajo de NewRenderer(e).Handler.
+func (e *Engine) Handler(name string, data DataFunc) http.Handler {
+	return NewRenderer(e).Handler(name, data)
+}
+
+// Handler renderiza name con los datos de data (puede ser nil) y responde 200.
+func (rd *Renderer) Handler(name string, data DataFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var v any
+		if data != nil {
+			var err error
+			if v, err = data(req); err != nil {
+				rd.Error(w, req, err)
+				return
+			}
+		}
+		rd.Render(w, req, http.StatusOK, name, v)
+	})
+}
+
+// Render renderiza name en memoria y, si no hubo error, lo escribe con el
+// estado indicado. Si falla responde la página de error con estado 500.
+func (rd *Renderer) Render(w http.ResponseWriter, req *http.Request, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := rd.engine.Render(name, data, &buf); err != nil {
+		rd.Error(w, req, err)
+		return
+	}
+	rd.write(w, status, rd.ContentType, buf.Bytes())
+}
+
+// Error registra err y responde la página de error con su código de estado
+// (el de un *HTTPError, o 500 si no hay uno válido). Si la página de error
+// también falla, responde texto plano.
+func (rd *Renderer) Error(w http.ResponseWriter, req *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var he *HTTPError
+	if errors.As(err, &he) {
+		status = validStatus(he.Status)
+	}
+	if status >= 500 {
+		rd.Logger.Printf("Teggo ▶ %s %s: %v", req.Method, req.URL.Path, err)
+	}
+
+	message := http.StatusText(status)
+	if rd.ShowErrors {
+		message = err.Error()
+	}
+	if rd.ErrorPage != "" {
+		var buf bytes.Buffer
+		data := ErrorPageData{Status: status, StatusText: http.StatusText(status), Message: message, Request: req}
+		perr := rd.engine.Render(rd.ErrorPage, data, &buf)
+		if perr == nil {
+			rd.write(w, status, rd.ContentType, buf.Bytes())
+			return
+		}
+		rd.Logger.Printf("Teggo ▶ error page %s: %v", rd.ErrorPage, perr)
+	}
+	rd.write(w, status, "text/plain; charset=utf-8", []byte(message+"\n"))
+}
+
+func (rd *Renderer) write(w http.ResponseWriter, status int, contentType string, body []byte) {
+	status = validStatus(status)
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	if status >= 400 {
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// validStatus devuelve status si WriteHeader lo acepta (100–999) y 500 si no.
+func validStatus(status int) int {
+	if status < 100 || status > 999 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
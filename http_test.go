@@ -0,0 +1,95 @@
+package teggo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderer_HandlerStatusAndErrorPage(t *testing.T) {
+	eng, err := New(WithFiles(map[string]string{
+		"components/Box.html": `{{tag Box}}{{/* props: N int! */}}<b>{{.N}}</b>{{end}}`,
+		"pages/Home.html":     `<h1>Hola {{.Name}}</h1><Box {...$.Box}></Box>`,
+		"pages/Error.html":    `<h1>{{.Status}}</h1><p>{{.Message}}</p>`,
+	}), WithErrorPolicy(PolicyStrict))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	rd := NewRenderer(eng)
+	rd.ErrorPage = "pages.Error"
+
+	cases := []struct {
+		name   string
+		data   DataFunc
+		status int
+		want   string
+	}{
+		{"ok", func(*http.Request) (any, error) {
+			return map[string]any{"Name": "Ana", "Box": map[string]any{"N": 1}}, nil
+		}, http.StatusOK, "<h1>Hola Ana</h1><b>1</b>"},
+		{"not found", func(*http.Request) (any, error) {
+			return nil, NewHTTPError(http.StatusNotFound, errors.New("no such user"))
+		}, http.StatusNotFound, "<h1>404</h1><p>Not Found</p>"},
+		// Falla a mitad del render: nada de la página llega al cliente.
+		{"render error", func(*http.Request) (any, error) {
+			return map[string]any{"Name": "Ana", "Box": map[string]any{"N": "x"}}, nil
+		}, http.StatusInternalServerError, "<h1>500</h1><p>Internal Server Error</p>"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		rd.Handler("pages.Home", tc.data).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: Content-Type %q", tc.name, ct)
+		}
+		if got := clean(rec.Body.String()); got != tc.want {
+			t.Errorf("%s: body %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// Sin página de error (o si falla) responde texto plano.
+	rd.ErrorPage = "pages.Missing"
+	rec := httptest.NewRecorder()
+	rd.Handler("pages.Home", func(*http.Request) (any, error) { return nil, errors.New("boom") }).
+		ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 500 || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("expected plain-text 500, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestRenderer_ErrorDetailsAndInvalidStatus(t *testing.T) {
+	eng, err := NewEngineFromSource(map[string]string{
+		"pages/Home.html":  `<p>ok</p>`,
+		"pages/Error.html": `<h1>{{.Status}}</h1><p>{{.Message}}</p>`,
+	}, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	rd := NewRenderer(eng)
+	rd.ErrorPage = "pages.Error"
+	serve := func(err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rd.Handler("pages.Home", func(*http.Request) (any, error) { return nil, err }).
+			ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		return rec
+	}
+
+	// El Debug del engine no expone el detalle al cliente.
+	if got := clean(serve(errors.New("secret /srv/views")).Body.String()); got != "<h1>500</h1><p>Internal Server Error</p>" {
+		t.Errorf("error details leaked without ShowErrors: %q", got)
+	}
+	rd.ShowErrors = true
+	if got := serve(errors.New("secret /srv/views")).Body.String(); !strings.Contains(got, "secret /srv/views") {
+		t.Errorf("expected error details with ShowErrors, got %q", got)
+	}
+
+	// Un código inválido responde 500 en lugar de entrar en pánico.
+	rd.ShowErrors = false
+	if rec := serve(NewHTTPError(0, errors.New("bad"))); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for an invalid status, got %d", rec.Code)
+	}
+}
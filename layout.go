@@ -0,0 +1,86 @@
+// layout.go
+// Paquete teggo — Layouts y herencia de páginas.
+// -----------------------------------------------------------------------------
+// Una página declara su layout con {{layout "layouts.Main"}}. La página entera
+// se transpila como una invocación del layout: cada <slot name="X"> de primer
+// nivel llena la región X y el resto del contenido es el slot anónimo. El layout
+// imprime las regiones igual que un componente ({{slot}}, {{slot name="Title"}})
+// y recibe además los datos de la página, tal cual, en .Page:
+//
+//	{{layout "layouts.Main"}}
+//	<slot name="Title">Inicio</slot>
+//	<p>Hola {{.User.Name}}</p>
+//
+// Un layout puede declarar a su vez otro layout; las regiones que no llena
+// pasan intactas al siguiente. La cadena se valida al crear el Engine.
+
+package teggo
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// resolveLayouts verifica que cada layout declarado exista en el set y que las
+// cadenas de layouts anidados no formen ciclos.
+func (e *Engine) resolveLayouts(set *template.Template, files map[string]sourceFile) error {
+	pages := make([]string, 0, len(e.parser.layouts))
+	for page := range e.parser.layouts {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+	for _, page := range pages {
+		chain := []string{page}
+		for name := page; ; {
+			ref, ok := e.parser.layouts[name]
+			if !ok {
+				break
+			}
+			if set.Lookup(ref.Name) == nil {
+				return e.layoutError(files, name, fmt.Errorf("layout %q not found", ref.Name))
+			}
+			for _, seen := range chain {
+				if seen == ref.Name {
+					cycle := strings.Join(append(chain, ref.Name), " -> ")
+					return e.layoutError(files, name, fmt.Errorf("layout cycle: %s", cycle))
+				}
+			}
+			chain = append(chain, ref.Name)
+			name = ref.Name
+		}
+	}
+	return nil
+}
+
+// layoutError ubica un error en la directiva {{layout}} de la página.
+func (e *Engine) layoutError(files map[string]sourceFile, page string, err error) *CompileError {
+	f := files[page]
+	ref := e.parser.layouts[page]
+	ce := &CompileError{File: f.Path, Component: page, Err: err}
+	ce.Line, ce.Column = offsetToLineCol(f.Content, ref.Pos)
+	ce.Snippet = lineText(f.Content, ce.Line)
+	return ce
+}
+
+// layoutData es el contexto de un layout: las regiones que llenó la página y
+// sus datos, sin modificar, en Page.
+type layoutData map[string]interface{}
+
+// layoutScope arma el contexto de un layout. Si dot ya es el de otro layout
+// (layouts anidados), conserva Page y las regiones que la página no llenó.
+func layoutScope(dot interface{}, regions map[string]interface{}) layoutData {
+	out := make(layoutData, len(regions)+1)
+	if parent, ok := dot.(layoutData); ok {
+		for k, v := range parent {
+			out[k] = v
+		}
+	} else {
+		out["Page"] = dot
+	}
+	for k, v := range regions {
+		out[k] = v
+	}
+	return out
+}
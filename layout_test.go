@@ -0,0 +1,165 @@
+package teggo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender_NestedLayouts(t *testing.T) {
+	files := map[string]string{
+		"layouts/Base.html": `<div class="doc"><h1>{{slot name="Title"}}</h1>{{slot}}<footer>{{.Page.Site}}</footer></div>`,
+		"layouts/Main.html": `{{layout "layouts.Base"}}
+<nav>{{slot name="Nav"}}</nav>
+<main>{{slot}}</main>`,
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html": `{{layout "layouts.Main"}}
+<slot name="Title">Hola {{.User}}</slot>
+<slot name="Nav"><a href="/">Inicio</a></slot>
+<For each=".Items" as="item"><Card>{{$item}}</Card></For>`,
+	}
+
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	if layout, _ := eng.parser.Layout("pages.Home"); layout != "layouts.Main" {
+		t.Errorf("expected pages.Home to use layouts.Main, got %q", layout)
+	}
+
+	var out strings.Builder
+	data := map[string]interface{}{"User": "Ana", "Site": "teggo.dev", "Items": []string{"a", "b"}}
+	if err := eng.Render("pages.Home", data, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	got := strings.Join(strings.Fields(out.String()), " ")
+	want := `<div class="doc"><h1>Hola Ana</h1><nav><a href="/">Inicio</a></nav> <main><div class="card">a</div><div class="card">b</div></main><footer>teggo.dev</footer></div>`
+	if got != want {
+		t.Errorf("Rendered output mismatch\n--- Got ---\n%s\n--- Want ---\n%s", got, want)
+	}
+}
+
+// layoutPage son datos de página con un método, que el layout debe poder llamar.
+type layoutPage struct {
+	Name string
+}
+
+func (p layoutPage) Year() int { return 2025 }
+
+func TestRender_LayoutReceivesPageDataAsIs(t *testing.T) {
+	files := map[string]string{
+		"layouts/Base.html": `<title>{{slot name="Title"}}</title><main>{{slot}}</main><footer>{{.Page.Name}} © {{.Page.Year}}</footer>`,
+		"layouts/Main.html": `{{layout "layouts.Base"}}<nav>{{.Page.Name}}</nav>{{slot}}`,
+		"pages/Home.html": `{{layout "layouts.Main"}}
+<slot name="Title">Hola {{.Name}}</slot>
+<p>{{.Year}}</p>`,
+		"layouts/Plain.html": `<h1>{{.Page}}</h1>{{slot}}`,
+		"pages/Text.html":    `{{layout "layouts.Plain"}}<p>{{.}}</p>`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	cases := []struct {
+		page string
+		data interface{}
+		want string
+	}{
+		{"pages.Home", layoutPage{Name: "Ana"}, `<title>Hola Ana</title><main><nav>Ana</nav><p>2025</p></main><footer>Ana © 2025</footer>`},
+		{"pages.Home", &layoutPage{Name: "Bob"}, `<title>Hola Bob</title><main><nav>Bob</nav><p>2025</p></main><footer>Bob © 2025</footer>`},
+	}
+	for _, tc := range cases {
+		var out strings.Builder
+		if err := eng.Render(tc.page, tc.data, &out); err != nil {
+			t.Fatalf("Engine failed to render: %v", err)
+		}
+		if got := strings.Join(strings.Fields(out.String()), " "); got != tc.want {
+			t.Errorf("Rendered output mismatch\n--- Got ---\n%s\n--- Want ---\n%s", got, tc.want)
+		}
+	}
+
+	// Datos que no son mapa ni struct también llegan al layout.
+	var out strings.Builder
+	if err := eng.Render("pages.Text", "hola", &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if want := `<h1>hola</h1><p>hola</p>`; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestCompile_LayoutErrors(t *testing.T) {
+	cases := map[string]map[string]string{
+		`layout "layouts.Missing" not found`: {
+			"pages/Home.html": "<p>a</p>\n{{layout \"layouts.Missing\"}}",
+		},
+		`layout cycle: layouts.A -> layouts.B -> layouts.A`: {
+			"layouts/A.html": "\n{{layout \"layouts.B\"}}{{slot}}",
+			"layouts/B.html": "\n{{layout \"layouts.A\"}}{{slot}}",
+		},
+	}
+	for want, files := range cases {
+		_, err := NewEngineFromSource(files, false)
+		var ce *CompileError
+		if !errors.As(err, &ce) || ce.Line != 2 || !strings.Contains(ce.Error(), want) {
+			t.Errorf("expected CompileError at line 2 with %q, got %v", want, err)
+		}
+	}
+}
+
+func TestRender_FullDocumentPages(t *testing.T) {
+	files := map[string]string{
+		"components/Meta.html":  `{{tag Meta}}<meta name="{{.Name}}" content="{{.Content}}">{{end}}`,
+		"components/Brand.html": `{{tag Brand}}{{.Name}}{{end}}`,
+		"components/Card.html":  `{{tag Card}}<script once src="/card.js"></script><div class="card">{{slot}}</div>{{end}}`,
+		"layouts/Doc.html": `<!DOCTYPE html>
+<html lang="es">
+<head>
+<title><Brand Name="Acme"/> - {{slot name="Title"}}</title>
+<Meta Name="generator" Content="teggo"/>
+{{slot name="Head"}}
+</head>
+<body>
+{{slot}}
+{{scripts}}
+</body>
+</html>`,
+		"pages/Home.html": `{{layout "layouts.Doc"}}
+<slot name="Title">Inicio</slot>
+<slot name="Head"><Meta Name="robots" Content="noindex"/></slot>
+<Card>hola</Card>`,
+		"pages/Plain.html": `<!DOCTYPE html><html><head><Meta Name="a" Content="b"/><title>x <Brand Name="&"/></title></head><body class="b"><Card>y</Card></body></html>`,
+	}
+
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	cases := map[string]string{
+		"pages.Home": `<!DOCTYPE html>
+<html lang="es">
+<head>
+<title>Acme - Inicio</title>
+<meta name="generator" content="teggo">
+<meta name="robots" content="noindex">
+</head>
+<body>
+<div class="card">hola</div>
+<script src="/card.js"></script>
+</body>
+</html>`,
+		"pages.Plain": `<!DOCTYPE html><html><head><meta name="a" content="b"><title>x &amp;</title></head><body class="b"><div class="card">y</div></body></html>`,
+	}
+	for page, want := range cases {
+		var out strings.Builder
+		if err := eng.Render(page, nil, &out); err != nil {
+			t.Fatalf("%s: Engine failed to render: %v", page, err)
+		}
+		// El documento empieza en su <!DOCTYPE>, sin espacios agregados.
+		if out.String() != want {
+			t.Errorf("%s mismatch\n--- Got ---\n%q\n--- Want ---\n%q", page, out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// options.go
+// Paquete teggo — Configuración del Engine: raíces, namespaces y nombres lógicos.
+// -----------------------------------------------------------------------------
+// Un nombre lógico se deriva siempre de la ruta del archivo relativa a su raíz,
+// con la misma función de nombres en todos los caminos (NewEngine*, recarga y
+// DebugParseTemplates):
+//
+//	Root{Dir: "./views"}                     views/pages/Home.html -> pages.Home
+//	Root{Dir: "./admin", Namespace: "admin"} admin/pages/Home.html -> admin:pages.Home
+
+package teggo
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Root es un directorio de templates. Si FS no es nil, Dir es una ruta dentro
+// de FS («.» o vacío para su raíz); si no, un directorio del SO.
+type Root struct {
+	Dir       string
+	Namespace string // Prefijo de los nombres lógicos: «admin» -> «admin:pages.Home».
+	FS        fs.FS
+}
+
+// NameFunc deriva el nombre lógico de un archivo a partir de su ruta relativa a
+// la raíz, siempre separada por «/» («pages/Home.html»).
+type NameFunc func(rel string) string
+
+// Logger recibe los mensajes del engine; *log.Logger lo implementa.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// Options configura la construcción de un Engine.
+type Options struct {
+	Roots    []Root
+	Paths    []string          // Archivos sueltos del SO (nombre relativo a su raíz, si tiene).
+	Files    map[string]string // Fuentes en memoria: ruta -> contenido.
+	Suffixes []string          // Patrones de archivo (*.html, …); vacío toma todos.
+	Naming   NameFunc          // Por defecto DefaultNaming.
+	Debug    bool
+
+	Funcs  template.FuncMap // Funciones propias, disponibles en páginas y componentes.
+	Logger Logger           // Por defecto el logger estándar (log.Default).
+	Strict bool             // Acceder a una clave inexistente es un error (missingkey=error).
+
+	ErrorPolicy ErrorPolicy // Qué hacer cuando falla un partial; por defecto PolicyLenient.
+	OnError     ErrorHook   // Se llama con cada error de componente.
+}
+
+// -----------------------------------------------------------------------------
+// Opciones funcionales
+// -----------------------------------------------------------------------------
+
+// Option modifica las Options de New.
+type Option func(*Options)
+
+// New construye un Engine con las opciones dadas:
+//
+//	engine, err := teggo.New(
+//		teggo.WithDir("./views"),
+//		teggo.WithSuffixes("*.html"),
+//		teggo.WithFuncs(template.FuncMap{"upper": strings.ToUpper}),
+//		teggo.WithStrict(true),
+//	)
+func New(opts ...Option) (*Engine, error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return NewEngineWithOptions(o)
+}
+
+// WithDir agrega un directorio del SO como raíz de templates.
+func WithDir(dir string) Option {
+	return WithRoot(Root{Dir: dir})
+}
+
+// WithRoot agrega una raíz (directorio o fs.FS, con namespace opcional).
+func WithRoot(root Root) Option {
+	return func(o *Options) { o.Roots = append(o.Roots, root) }
+}
+
+// WithFS agrega dir dentro de fsys como raíz de templates.
+func WithFS(fsys fs.FS, dir string) Option {
+	return WithRoot(Root{FS: fsys, Dir: dir})
+}
+
+// WithPaths agrega archivos sueltos del SO.
+func WithPaths(paths ...string) Option {
+	return func(o *Options) { o.Paths = append(o.Paths, paths...) }
+}
+
+// WithFiles agrega fuentes en memoria (ruta -> contenido).
+func WithFiles(files map[string]string) Option {
+	return func(o *Options) {
+		if o.Files == nil {
+			o.Files = make(map[string]string, len(files))
+		}
+		for p, content := range files {
+			o.Files[p] = content
+		}
+	}
+}
+
+// WithSuffixes define los patrones de archivo de las raíces (*.html, *.gotpl).
+func WithSuffixes(suffixes ...string) Option {
+	return func(o *Options) { o.Suffixes = append(o.Suffixes, suffixes...) }
+}
+
+// WithNaming reemplaza la función de nombres lógicos.
+func WithNaming(naming NameFunc) Option {
+	return func(o *Options) { o.Naming = naming }
+}
+
+// WithDebug activa los mensajes de depuración.
+func WithDebug(debug bool) Option {
+	return func(o *Options) { o.Debug = debug }
+}
+
+// WithFuncs agrega funciones propias a las de Teggo.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(o *Options) {
+		if o.Funcs == nil {
+			o.Funcs = make(template.FuncMap, len(funcs))
+		}
+		for name, fn := range funcs {
+			o.Funcs[name] = fn
+		}
+	}
+}
+
+// WithLogger define dónde se registran los errores del engine.
+func WithLogger(logger Logger) Option {
+	return func(o *Options) { o.Logger = logger }
+}
+
+// WithStrict hace que acceder a una clave inexistente (.Foo en un mapa sin Foo)
+// sea un error de render en lugar de imprimir «<no value>».
+func WithStrict(strict bool) Option {
+	return func(o *Options) { o.Strict = strict }
+}
+
+// WithErrorPolicy define qué pasa cuando falla un componente (ver ErrorPolicy).
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *Options) { o.ErrorPolicy = policy }
+}
+
+// WithErrorHook registra un callback para cada error de componente.
+func WithErrorHook(hook ErrorHook) Option {
+	return func(o *Options) { o.OnError = hook }
+}
+
+// logger retorna el Logger configurado o el estándar.
+func (o *Options) logger() Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return log.Default()
+}
+
+// DefaultNaming quita la extensión y reemplaza «/» por «.»: «pages/Home.html»
+// -> «pages.Home». Descarta los prefijos «./», «../» y «/».
+func DefaultNaming(rel string) string {
+	rel = path.Clean(filepath.ToSlash(rel))
+	for {
+		switch {
+		case strings.HasPrefix(rel, "../"):
+			rel = rel[3:]
+		case strings.HasPrefix(rel, "/"):
+			rel = rel[1:]
+		default:
+			return strings.ReplaceAll(strings.TrimSuffix(rel, path.Ext(rel)), "/", ".")
+		}
+	}
+}
+
+// name resuelve el nombre lógico de rel dentro de root.
+func (o *Options) name(root Root, rel string) string {
+	naming := o.Naming
+	if naming == nil {
+		naming = DefaultNaming
+	}
+	name := naming(filepath.ToSlash(rel))
+	if root.Namespace != "" {
+		name = root.Namespace + ":" + name
+	}
+	return name
+}
+
+// nameOf resuelve el nombre lógico de una ruta del SO: relativa a la primera
+// raíz del SO que la contiene o, si ninguna, la ruta tal cual.
+func (o *Options) nameOf(p string) string {
+	for _, root := range o.Roots {
+		if root.FS != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root.Dir, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return o.name(root, rel)
+		}
+	}
+	return o.name(Root{}, p)
+}
+
+// sourceFile es un archivo a compilar con su nombre lógico ya resuelto.
+type sourceFile struct {
+	Path    string // Ruta para mensajes de error.
+	Name    string
+	Content string
+}
+
+// load descubre y lee los archivos de todas las raíces, los sueltos y los en memoria.
+func (o *Options) load() ([]sourceFile, error) {
+	var files []sourceFile
+	for _, root := range o.Roots {
+		if root.FS != nil {
+			dir := root.Dir
+			if dir == "" {
+				dir = "."
+			}
+			for _, p := range DiscoverFS(root.FS, dir, o.Suffixes...) {
+				b, err := fs.ReadFile(root.FS, p)
+				if err != nil {
+					return nil, fmt.Errorf("reading %s: %w", p, err)
+				}
+				rel := p
+				if dir != "." {
+					rel = strings.TrimPrefix(p, dir+"/")
+				}
+				files = append(files, sourceFile{Path: p, Name: o.name(root, rel), Content: string(b)})
+			}
+			continue
+		}
+		if _, err := os.Stat(root.Dir); err != nil {
+			return nil, err
+		}
+		for _, p := range Discover(root.Dir, o.Suffixes...) {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", p, err)
+			}
+			rel, err := filepath.Rel(root.Dir, p)
+			if err != nil {
+				rel = p
+			}
+			files = append(files, sourceFile{Path: p, Name: o.name(root, rel), Content: string(b)})
+		}
+	}
+	for _, p := range o.Paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", p, err)
+		}
+		files = append(files, sourceFile{Path: p, Name: o.nameOf(p), Content: string(b)})
+	}
+	return append(files, o.sourceFiles(o.Files)...), nil
+}
+
+// NewEngineWithOptions descubre, lee y compila los templates de opts.Roots.
+func NewEngineWithOptions(opts Options) (*Engine, error) {
+	files, err := opts.load()
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(opts, files)
+}
+
+// sourceFiles arma la lista de archivos de un mapa ruta -> contenido, con
+// nombres lógicos derivados de la ruta completa.
+func (o *Options) sourceFiles(files map[string]string) []sourceFile {
+	out := make([]sourceFile, 0, len(files))
+	for p, content := range files {
+		out = append(out, sourceFile{Path: p, Name: o.nameOf(p), Content: content})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
@@ -0,0 +1,153 @@
+package teggo
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewEngineWithOptions_RootsNamespacesAndNaming(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html":      `<Card>público</Card>`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	admin := fstest.MapFS{
+		"admin/pages/Home.html":   {Data: []byte(`{{layout "admin:layouts.Main"}}<Card>admin</Card>`)},
+		"admin/layouts/Main.html": {Data: []byte(`<main>{{slot}}</main>`)},
+	}
+
+	opts := Options{
+		Roots: []Root{
+			{Dir: dir},
+			{FS: admin, Dir: "admin", Namespace: "admin"},
+		},
+		Suffixes: []string{"*.html"},
+	}
+	eng, err := NewEngineWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	for name, want := range map[string]string{
+		"pages.Home":       `<div class="card">público</div>`,
+		"admin:pages.Home": `<main><div class="card">admin</div></main>`,
+	} {
+		var out strings.Builder
+		if err := eng.Render(name, nil, &out); err != nil {
+			t.Fatalf("render %s: %v", name, err)
+		}
+		if got := clean(out.String()); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	// DebugParseTemplates usa los mismos nombres que el engine.
+	if err := eng.DebugParseTemplates(Discover(dir, "*.html")); err != nil {
+		t.Errorf("DebugParseTemplates: %v", err)
+	}
+	if got := opts.nameOf(filepath.Join(dir, "pages", "Home.html")); got != "pages.Home" {
+		t.Errorf("nameOf: got %q", got)
+	}
+
+	// Función de nombres propia; dos archivos con el mismo nombre lógico fallan.
+	opts.Naming = func(rel string) string {
+		parts := strings.Split(DefaultNaming(rel), ".")
+		return parts[len(parts)-1]
+	}
+	opts.Roots = opts.Roots[:1]
+	if _, err := NewEngineWithOptions(opts); err != nil {
+		t.Errorf("custom naming: %v", err)
+	}
+	opts.Roots = append(opts.Roots, Root{FS: fstest.MapFS{"x/Home.html": {Data: []byte(`<p>otra</p>`)}}})
+	var ce *CompileError
+	if _, err := NewEngineWithOptions(opts); !errors.As(err, &ce) || !strings.Contains(ce.Error(), `logical name "Home" already used`) {
+		t.Errorf("expected duplicated logical name error, got %v", err)
+	}
+}
+
+func TestDefaultNaming(t *testing.T) {
+	for in, want := range map[string]string{
+		"pages/Home.html":             "pages.Home",
+		"./examples/pages/Home.html":  "examples.pages.Home",
+		"../views/components/Card.go": "views.components.Card",
+		"/abs/Layout.gotpl":           "abs.Layout",
+	} {
+		if got := DefaultNaming(in); got != want {
+			t.Errorf("DefaultNaming(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type captureLogger struct{ lines []string }
+
+func (l *captureLogger) Printf(format string, v ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestNew_FunctionalOptions(t *testing.T) {
+	logger := &captureLogger{}
+	files := map[string]string{
+		"components/Box.html": `{{tag Box}}{{/* props: N int! */}}<b>{{.N}}</b>{{end}}`,
+		"pages/Home.html":     `<p>{{upper .Name}}</p>{{partial "Box" .Box}}`,
+	}
+	eng, err := New(
+		WithFiles(files),
+		WithFuncs(template.FuncMap{"upper": strings.ToUpper}),
+		WithLogger(logger),
+	)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]any{"Name": "ana", "Box": map[string]any{}}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if got := clean(out.String()); got != "<p>ANA</p>" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], `missing required prop "N"`) {
+		t.Errorf("expected the partial failure in the custom logger, got %q", logger.lines)
+	}
+
+	// Strict: una clave inexistente es un error de render.
+	strict, err := New(WithFiles(map[string]string{"pages/Home.html": `<p>{{.Missing}}</p>`}), WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strict.Render("pages.Home", map[string]any{}, &out); err == nil {
+		t.Error("expected a missing key error in strict mode")
+	}
+}
+
+func TestRender_StrictAllowsOmittedOptionalSlots(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<div>{{slot}}<footer>{{slot name="Footer"}}</footer></div>{{end}}`,
+		"layouts/Main.html":    `<aside>{{slot name="Nav"}}</aside><main>{{slot}}</main>`,
+		"pages/Home.html":      `{{layout "layouts.Main"}}<Card>hi</Card><Card></Card>`,
+	}
+	eng, err := New(WithFiles(files), WithStrict(true))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]any{}, &out); err != nil {
+		t.Fatalf("omitted optional slots should render empty in strict mode: %v", err)
+	}
+	want := `<aside></aside><main><div>hi<footer></footer></div><div><footer></footer></div></main>`
+	if got := clean(out.String()); got != want {
+		t.Errorf("Rendered output mismatch\n--- Got ---\n%s\n--- Want ---\n%s", got, want)
+	}
+}
@@ -7,20 +7,108 @@ package teggo
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-
-	"golang.org/x/net/html"
 )
 
 // -----------------------------------------------------------------------------
-// ComponentRegistry — Global compartido con el Engine.
+// Parser — Transpilador con registro de componentes propio.
 // -----------------------------------------------------------------------------
-var componentRegistry = map[string]struct{}{}
 
-func SetComponentRegistry(reg map[string]struct{}) {
-	componentRegistry = reg
+// Parser convierte fuentes Teggo en GoTpl. Cada Engine tiene el suyo, así varios
+// Engines pueden construirse en paralelo sin compartir estado. Registrar
+// componentes no es seguro en concurrencia con Parse; parsear sí lo es.
+type Parser struct {
+	components map[string]struct{}
+	props      map[string][]PropDef
+	styles     map[string]string    // CSS con alcance, por componente
+	scripts    map[string][]string  // <script once>, por componente
+	head       map[string][]string  // Contenido de <head>, por componente
+	layouts    map[string]layoutRef // Layout declarado, por página
+	flush      bool                 // Alguna página usa <Flush/>
+	sites      []callSite           // Llamadas a componentes, por id (ver trace.go)
+}
+
+// layoutRef es la directiva {{layout "..."}} de una página.
+type layoutRef struct {
+	Name string
+	Pos  int // Offset de la directiva en el original
+	Site int // Id de la llamada al layout (ver trace.go)
+}
+
+// NewParser crea un Parser sin componentes registrados.
+func NewParser() *Parser {
+	return &Parser{
+		components: make(map[string]struct{}),
+		props:      make(map[string][]PropDef),
+		styles:     make(map[string]string),
+		scripts:    make(map[string][]string),
+		head:       make(map[string][]string),
+		layouts:    make(map[string]layoutRef),
+	}
+}
+
+// RegisterComponent registra un componente y sus props declaradas (puede ser nil).
+func (p *Parser) RegisterComponent(name string, props []PropDef) {
+	p.components[name] = struct{}{}
+	if props != nil {
+		p.props[name] = props
+	}
+}
+
+// registryCopy devuelve un Parser nuevo con los mismos componentes y props
+// registrados, sin assets, layouts ni llamadas de parseos anteriores.
+func (p *Parser) registryCopy() *Parser {
+	c := NewParser()
+	for name := range p.components {
+		c.components[name] = struct{}{}
+	}
+	for name, defs := range p.props {
+		c.props[name] = defs
+	}
+	return c
+}
+
+// IsComponent indica si name es un componente registrado.
+func (p *Parser) IsComponent(name string) bool {
+	_, ok := p.components[name]
+	return ok
+}
+
+// Props retorna las props declaradas por un componente registrado.
+func (p *Parser) Props(name string) ([]PropDef, bool) {
+	defs, ok := p.props[name]
+	return defs, ok
+}
+
+// Styles retorna el CSS con alcance de un componente ya parseado.
+func (p *Parser) Styles(name string) (string, bool) {
+	css, ok := p.styles[name]
+	return css, ok
+}
+
+// hasAssets indica si un componente emite la marca de uso (ver Engine.injectAssets).
+func (p *Parser) hasAssets(name string) bool {
+	_, css := p.styles[name]
+	_, js := p.scripts[name]
+	_, head := p.head[name]
+	return css || js || head
+}
+
+// Layout retorna el layout declarado por una página ya parseada.
+func (p *Parser) Layout(page string) (string, bool) {
+	ref, ok := p.layouts[page]
+	return ref.Name, ok
+}
+
+// anyAssets indica si algún componente parseado declaró assets.
+func (p *Parser) anyAssets() bool {
+	return len(p.styles)+len(p.scripts)+len(p.head) > 0
 }
 
 // -----------------------------------------------------------------------------
@@ -30,17 +118,56 @@ var (
 	tagPattern       = regexp.MustCompile(`{{\s*tag\s+(\w+)\s*}}`)
 	slotNamedPattern = regexp.MustCompile(`{{\s*slot\s+name\s*=\s*"(.*?)"\s*}}`)
 	slotAnonPattern  = regexp.MustCompile(`{{\s*slot\s*}}`)
-	mustacheBlock    = regexp.MustCompile(`{{.*?}}`)
+	flushPattern     = regexp.MustCompile(`{{-?\s*flush\s*-?}}|<Flush\b`)
+	layoutPattern    = regexp.MustCompile(`^{{-?\s*layout\s+"([^"]+)"\s*-?}}$`)
+	attrPattern      = regexp.MustCompile(`\{\.\.\.\s*([^}]*?)\s*\}|([^\s"'>/=]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
 )
 
+// componentAttr es un atributo de un tag de componente tal como fue escrito
+// (ver lexAttrs).
+type componentAttr struct {
+	Key    string
+	Val    string
+	Spread bool // {...expr}: Key contiene la expresión a esparcir
+	Expr   bool // Val es un pipeline GoTpl (:Title=".X", Title={{.X}}, o «true» en atributos sin valor)
+}
+
 // -----------------------------------------------------------------------------
 // Entrada principal
 // -----------------------------------------------------------------------------
-func ParseTagsToGoTpl(source, base, logicalName string) string {
+
+// Parse transpila un archivo: componente ({{tag Name}}) o página.
+func (p *Parser) Parse(source, logicalName string) (string, error) {
+	out, _, err := p.parse(source, logicalName)
+	return out, err
+}
+
+// parse transpila y devuelve además el mapa de fuente del resultado. Los
+// errores del walker se devuelven como posError (ver sourceMap.errorAt).
+func (p *Parser) parse(source, logicalName string) (string, *sourceMap, error) {
+	if flushPattern.MatchString(source) {
+		p.flush = true
+	}
 	if hasTagDirective(source) {
-		return parseComponent(source)
+		out, err := p.parseComponent(source)
+		return out, identityMap(source, out, 0), err
 	}
-	return parsePage(source, logicalName)
+	return p.parsePage(source, logicalName)
+}
+
+// ParseTagsToGoTpl transpila con un Parser sin componentes registrados. Si la
+// fuente tiene errores, los registra con el logger estándar y devuelve "". base
+// se conserva por compatibilidad y no se usa.
+//
+// Deprecated: usa Parser.Parse, que conoce los componentes del Engine y
+// devuelve el error.
+func ParseTagsToGoTpl(source, base, logicalName string) string {
+	out, err := NewParser().Parse(source, logicalName)
+	if err != nil {
+		log.Printf("Teggo ▶ %s: %v", logicalName, err)
+		return ""
+	}
+	return out
 }
 
 // Detecta si es un componente con {{tag Name}}
@@ -51,239 +178,442 @@ func hasTagDirective(source string) bool {
 // -----------------------------------------------------------------------------
 // Conversión de definición de componente
 // -----------------------------------------------------------------------------
-func parseComponent(source string) string {
+func (p *Parser) parseComponent(source string) (string, error) {
+	name := getTagName(source)
+
+	// <script once> y <head>: assets del documento, se emiten una vez por render
+	source, scripts, head, err := extractDocumentAssets(source, name)
+	if err != nil {
+		return "", err
+	}
+	if len(scripts) > 0 && name != "" {
+		p.scripts[name] = scripts
+	}
+	if len(head) > 0 && name != "" {
+		p.head[name] = head
+	}
+
+	// <style scoped>: CSS con alcance y atributo en los elementos raíz
+	source, css := extractScopedStyle(source)
+	if css != "" && name != "" {
+		attr := scopeAttr(name)
+		p.styles[name] = scopeCSS(css, attr)
+		source = addRootAttr(source, attr)
+	}
+
 	out := tagPattern.ReplaceAllStringFunc(source, func(m string) string {
 		match := tagPattern.FindStringSubmatch(m)
 		if len(match) > 1 {
+			if p.hasAssets(match[1]) {
+				// Marca de uso para recolectar assets por render
+				return fmt.Sprintf(`{{define "%s"}}{{track "%s"}}`, match[1], match[1])
+			}
 			return fmt.Sprintf(`{{define "%s"}}`, match[1])
 		}
 		return m
 	})
-	out = slotNamedPattern.ReplaceAllString(out, `{{template "$1" .}}`)
-	out = slotAnonPattern.ReplaceAllString(out, `{{template "slot" .}}`)
-	return out
+	return rewriteSlots(out), nil
+}
+
+// rewriteSlots convierte {{slot}} y {{slot name="X"}} en la impresión del slot
+// recibido: en componentes son props, en layouts son las regiones de la página.
+// Se leen con index para que un slot opcional omitido no falle con Strict
+// (missingkey=error).
+func rewriteSlots(src string) string {
+	src = slotNamedPattern.ReplaceAllString(src, `{{render (index $$ "$1")}}`)
+	return slotAnonPattern.ReplaceAllString(src, `{{render (index $$ "Slot")}}`)
 }
 
 // -----------------------------------------------------------------------------
 // Conversión de página (uso de componentes en JSX-like)
 // -----------------------------------------------------------------------------
-func parsePage(source, logicalName string) string {
-	// 1️⃣ Extraer y proteger bloques GoTpl
-	cleanSrc, blocks := extractTemplateBlocks(source)
-
-	// 2️⃣ Marcar componentes registrados con teggo-component
-	markedSrc := markComponentTags(cleanSrc)
-
-	// 3️⃣ Parsear como HTML
-	node, err := html.Parse(strings.NewReader(markedSrc))
+func (p *Parser) parsePage(source, logicalName string) (string, *sourceMap, error) {
+	// 1️⃣ Tokenizar: componentes, tags de control, <slot> y bloques GoTpl
+	root, err := parseTeggo(source, func(name string) bool {
+		return p.IsComponent(name) || isControlTag(name)
+	})
 	if err != nil {
-		return wrapAsDefine(logicalName, source)
+		return "", identityMap(source, "", 0), err
 	}
 
-	// 4️⃣ Buscar contenido real (body)
-	body := findBody(node)
-	if body == nil {
-		return wrapAsDefine(logicalName, source)
+	// 2️⃣ {{layout "..."}} se retira; {{slot}} imprime regiones si la página es un layout
+	layout := layoutRef{Pos: -1}
+	var dup *node
+	eachAction(root, func(n *node) {
+		if m := layoutPattern.FindStringSubmatch(n.Text); m != nil {
+			if layout.Pos >= 0 && dup == nil {
+				dup = n
+			}
+			layout = layoutRef{Name: m[1], Pos: n.Pos}
+			n.Text = ""
+			return
+		}
+		n.Text = rewriteSlots(n.Text)
+	})
+	if dup != nil {
+		return "", identityMap(source, "", 0), &posError{pos: dup.Pos, component: logicalName, err: fmt.Errorf("duplicated layout directive")}
+	}
+	if layout.Pos >= 0 {
+		p.layouts[logicalName] = layout
+	} else {
+		delete(p.layouts, logicalName)
 	}
 
-	// 5️⃣ Procesar nodos
-	slotCounter := 0
-	var slotDefs []string
+	// 3️⃣ Procesar nodos
+	w := &pageWalker{parser: p, logicalPath: logicalName}
 	var buf bytes.Buffer
 
-	for c := body.FirstChild; c != nil; c = c.NextSibling {
-		if err := walkNode(&buf, c, logicalName, &slotCounter, &slotDefs, blocks); err != nil {
-			return wrapAsDefine(logicalName, source)
+	walk := func() error { return w.walkChildren(&buf, root) }
+	if layout.Pos >= 0 {
+		// La página entera es la invocación del layout: <slot name="X"> de primer
+		// nivel llena la región X y el resto es el slot anónimo. El layout recibe
+		// además los datos de la página en .Page (ver layoutScope).
+		walk = func() error {
+			buf.WriteString(w.mark(layout.Pos, layout.Name))
+			return w.renderCall(&buf, root, layout.Name, layout.Pos, nil, true)
 		}
 	}
+	if err := walk(); err != nil {
+		sm := identityMap(source, "", 0)
+		return "", sm, err
+	}
 
-	// 6️⃣ Generar define principal
+	// 4️⃣ Generar define principal (sin espacios agregados: un documento
+	// completo debe empezar en su <!DOCTYPE>)
 	var final bytes.Buffer
 	final.WriteString(fmt.Sprintf(`{{define "%s"}}`, logicalName))
-	final.WriteString("\n")
 	final.WriteString(buf.String())
-	final.WriteString("\n{{end}}\n")
+	final.WriteString("{{end}}\n")
 
-	// 7️⃣ Adjuntar defines de slots hijos
-	for _, def := range slotDefs {
+	// 5️⃣ Adjuntar defines de slots hijos
+	for _, def := range w.slotDefs {
 		final.WriteString(def)
 		final.WriteString("\n")
 	}
 
-	fmt.Println(final.String())
-
-	return final.String()
+	// 6️⃣ Retirar marcas y armar el mapa de fuente
+	sm := stripSourceMarks(source, final.String(), w.anchors)
+	return sm.generated, sm, nil
 }
 
-// -----------------------------------------------------------------------------
-// Reemplazo previo: Marcar componentes Teggo válidos
-// -----------------------------------------------------------------------------
-func markComponentTags(input string) string {
-	for comp := range componentRegistry {
-		// Reemplazar apertura
-		openTag := regexp.MustCompile(fmt.Sprintf(`<%s(\b|>)`, regexp.QuoteMeta(comp)))
-		input = openTag.ReplaceAllString(input, fmt.Sprintf(`<teggo-component teggo:name="%s"$1`, comp))
-
-		// Reemplazar cierre
-		closeTag := regexp.MustCompile(fmt.Sprintf(`</%s>`, regexp.QuoteMeta(comp)))
-		input = closeTag.ReplaceAllString(input, `</teggo-component>`)
+// eachAction recorre en orden los bloques GoTpl del árbol.
+func eachAction(n *node, fn func(*node)) {
+	for _, c := range n.Children {
+		switch c.Kind {
+		case actionNode:
+			fn(c)
+		case tagNode:
+			eachAction(c, fn)
+		}
 	}
-	return input
+}
+
+// mustacheInner devuelve el pipeline de un bloque {{...}} sin delimitadores ni marcas de recorte.
+func mustacheInner(block string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(block, "{{"), "}}")
+	inner = strings.TrimPrefix(inner, "- ")
+	inner = strings.TrimSuffix(inner, " -")
+	return strings.TrimSpace(inner)
 }
 
 // -----------------------------------------------------------------------------
-// Helpers para parseo y reemplazo
+// Caminar el árbol de la página
 // -----------------------------------------------------------------------------
-func wrapAsDefine(name, body string) string {
-	return fmt.Sprintf(`{{define "%s"}}
-%s
-{{end}}`, name, body)
-}
-
-func extractTemplateBlocks(input string) (string, []string) {
-	blocks := []string{}
-	output := mustacheBlock.ReplaceAllStringFunc(input, func(m string) string {
-		idx := len(blocks)
-		blocks = append(blocks, m)
-		return fmt.Sprintf("__TPL_%d__", idx)
-	})
-	return output, blocks
-}
 
-func restoreTemplateBlocks(input string, blocks []string) string {
-	out := input
-	for i, block := range blocks {
-		out = strings.ReplaceAll(out, fmt.Sprintf("__TPL_%d__", i), block)
-	}
-	return out
+// pageWalker mantiene el estado de la conversión de una página: defines de
+// slots generados y variables declaradas por tags de control (<For as index>).
+type pageWalker struct {
+	parser      *Parser
+	logicalPath string
+	counter     int
+	slotDefs    []string
+	vars        []string            // Variables en alcance (<For as>, {{$x := …}}, {{range $u := …}})
+	scopes      [][]string          // Variables al abrir cada bloque {{if}}/{{with}}/{{range}} en curso
+	loops       int                 // Profundidad de <For> en el punto actual
+	slots       int                 // Profundidad de contenido de slot en el punto actual
+	fragments   map[string]struct{} // <Fragment name> ya definidos
+	anchors     []anchor            // Puntos del mapa de fuente (ver errors.go)
 }
 
-func findBody(n *html.Node) *html.Node {
-	if n.Type == html.ElementNode && n.Data == "body" {
-		return n
-	}
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if res := findBody(c); res != nil {
-			return res
-		}
-	}
-	return nil
-}
+func (w *pageWalker) walkNode(buf *bytes.Buffer, n *node) error {
+	switch n.Kind {
+	case textNode:
+		buf.WriteString(n.Text)
 
-// -----------------------------------------------------------------------------
-// Caminar el árbol HTML parseado
-// -----------------------------------------------------------------------------
-func walkNode(buf *bytes.Buffer, n *html.Node, logicalPath string, counter *int, slotDefs *[]string, blocks []string) error {
-	switch n.Type {
-	case html.TextNode:
-		buf.WriteString(restoreTemplateBlocks(n.Data, blocks))
-
-	case html.ElementNode:
-		if strings.HasPrefix(n.Data, "__TPL_") {
-			buf.WriteString(n.Data)
-			return nil
-		}
+	case commentNode:
+		// html/template omite los comentarios del texto; se imprimen como HTML.
+		fmt.Fprintf(buf, "{{htmlComment %q}}", n.Text)
 
-		// Teggo-component marcado
-		if n.Data == "teggo-component" {
-			var compName string
-			props := []html.Attribute{}
-			for _, a := range n.Attr {
-				if a.Key == "teggo:name" {
-					compName = a.Val
-				} else {
-					props = append(props, a)
-				}
-			}
+	case actionNode:
+		if w.slots > 0 && flushAction.MatchString(n.Text) {
+			return &posError{pos: n.Pos, err: errFlushInSlot}
+		}
+		w.trackAction(n.Text)
+		if n.Text != "" {
+			buf.WriteString(w.mark(n.Pos, ""))
+			buf.WriteString(n.Text)
+		}
 
-			if compName != "" && isRegisteredComponent(compName) {
-				return renderComponent(buf, &html.Node{
-					Type:       html.ElementNode,
-					Data:       compName,
-					Attr:       props,
-					FirstChild: n.FirstChild,
-				}, compName, logicalPath, counter, slotDefs, blocks)
-			}
+	case tagNode:
+		if handled, err := w.renderTag(buf, n); handled {
+			return err
 		}
 
-		// Tag HTML normal
-		buf.WriteString("<" + n.Data)
-		for _, attr := range n.Attr {
-			buf.WriteString(fmt.Sprintf(` %s="%s"`, attr.Key, attr.Val))
+		// <slot> fuera de un componente: se emite tal cual
+		buf.WriteString(n.Text)
+		if err := w.walkChildren(buf, n); err != nil {
+			return err
 		}
-		buf.WriteString(">")
+		buf.WriteString(n.Close)
+	}
+	return nil
+}
 
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			if err := walkNode(buf, c, logicalPath, counter, slotDefs, blocks); err != nil {
-				return err
-			}
+// renderTag transpila un tag de componente o de control. Devuelve handled=false
+// si el nombre no corresponde a ninguno (se emite como HTML normal).
+func (w *pageWalker) renderTag(buf *bytes.Buffer, n *node) (handled bool, err error) {
+	switch {
+	case isControlTag(n.Name):
+		buf.WriteString(w.mark(n.Pos, n.Name))
+		err = w.renderControl(buf, n, n.Name, n.Attrs)
+	case w.parser.IsComponent(n.Name):
+		buf.WriteString(w.mark(n.Pos, n.Name))
+		err = w.renderComponent(buf, n, n.Name, n.Pos, n.Attrs)
+	default:
+		return false, nil
+	}
+	var pe *posError
+	if err != nil && !errors.As(err, &pe) {
+		err = &posError{pos: n.Pos, component: n.Name, err: err}
+	}
+	return true, err
+}
+
+func (w *pageWalker) walkChildren(buf *bytes.Buffer, n *node) error {
+	for _, c := range n.Children {
+		if err := w.walkNode(buf, c); err != nil {
+			return err
 		}
-		buf.WriteString("</" + n.Data + ">")
 	}
 	return nil
 }
 
-// Verifica si es un componente Teggo registrado
-func isRegisteredComponent(name string) bool {
-	_, ok := componentRegistry[name]
-	return ok
+// mark registra un punto del mapa de fuente y devuelve la marca a intercalar.
+func (w *pageWalker) mark(orig int, label string) string {
+	w.anchors = append(w.anchors, anchor{orig: orig, label: label})
+	return sourceMark + strconv.Itoa(len(w.anchors)-1) + sourceMark
+}
+
+// Renderiza la llamada al template GoTpl.
+// Los spreads se combinan en orden y las props explícitas (y slots) ganan:
+//
+//	<UserCard {...$user} ShowActions="true">  ->  merge (spread $user) (dict "ShowActions" "true")
+func (w *pageWalker) renderComponent(buf *bytes.Buffer, n *node, componentName string, pos int, attrs []componentAttr) error {
+	return w.renderCall(buf, n, componentName, pos, attrs, false)
 }
 
-// Renderiza la llamada al template GoTpl
-func renderComponent(buf *bytes.Buffer, n *html.Node, componentName, logicalPath string, counter *int, slotDefs *[]string, blocks []string) error {
-	// Props
-	props := map[string]string{}
-	for _, attr := range n.Attr {
-		props[attr.Key] = attr.Val
+// renderCall genera la llamada a un componente o, con layout, al layout de la
+// página: sus regiones más los datos de la página (layoutScope $ (dict …)).
+func (w *pageWalker) renderCall(buf *bytes.Buffer, n *node, componentName string, pos int, attrs []componentAttr, layout bool) error {
+	// Props y spreads
+	var spreads []string
+	var props []componentAttr
+	for _, attr := range attrs {
+		if attr.Spread {
+			spreads = append(spreads, attr.Key)
+		} else {
+			props = append(props, attr)
+		}
 	}
 
-	// Slots
-	childSlots := map[string]string{}
+	// Slots: se ejecutan en memoria antes de imprimirse (ver errFlushInSlot)
+	var childSlots [][2]string
 	var anonSlotContent bytes.Buffer
-
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if c.Type == html.ElementNode && c.Data == "slot" {
+	w.slots++
+	defer func() { w.slots-- }()
+
+	// Cada slot es un define propio: lo que declara no sale de él. Las
+	// variables en alcance en la llamada se reenvían (ver defineSlot).
+	outer, outerScopes := slices.Clip(w.vars), slices.Clip(w.scopes)
+	anon, anonScopes := outer, outerScopes
+	for _, c := range n.Children {
+		if c.Kind == tagNode && c.Name == "slot" {
 			// Slot nombrado o anónimo
-			nameAttr := "slot"
-			for _, a := range c.Attr {
-				if a.Key == "name" {
+			nameAttr := "Slot"
+			for _, a := range c.Attrs {
+				if a.Key == "name" && !a.Spread && !a.Expr {
 					nameAttr = a.Val
 					break
 				}
 			}
 			var slotBuf bytes.Buffer
-			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
-				walkNode(&slotBuf, gc, logicalPath, counter, slotDefs, blocks)
+			w.vars, w.scopes = outer, outerScopes
+			if err := w.walkChildren(&slotBuf, c); err != nil {
+				return err
 			}
-			slotName := slotDefineName(logicalPath, componentName, nameAttr, *counter)
-			*slotDefs = append(*slotDefs, fmt.Sprintf(`{{define "%s"}}%s{{end}}`, slotName, slotBuf.String()))
-			childSlots[nameAttr] = slotName
-			*counter++
+			w.vars, w.scopes = outer, outerScopes
+			childSlots = append(childSlots, [2]string{nameAttr, w.defineSlot(componentName, nameAttr, slotBuf.String())})
 		} else {
 			// Slot anónimo
-			walkNode(&anonSlotContent, c, logicalPath, counter, slotDefs, blocks)
+			w.vars, w.scopes = anon, anonScopes
+			if err := w.walkNode(&anonSlotContent, c); err != nil {
+				return err
+			}
+			anon, anonScopes = slices.Clip(w.vars), slices.Clip(w.scopes)
 		}
 	}
+	w.vars, w.scopes = outer, outerScopes
 
 	if strings.TrimSpace(anonSlotContent.String()) != "" {
-		slotName := slotDefineName(logicalPath, componentName, "slot", *counter)
-		*slotDefs = append(*slotDefs, fmt.Sprintf(`{{define "%s"}}%s{{end}}`, slotName, anonSlotContent.String()))
-		childSlots["slot"] = slotName
-		*counter++
+		childSlots = append(childSlots, [2]string{"Slot", w.defineSlot(componentName, "Slot", anonSlotContent.String())})
+	}
+
+	// Validar contra las props declaradas
+	defs, typed := w.parser.Props(componentName)
+	if typed && len(spreads) == 0 {
+		if err := checkRequiredProps(componentName, defs, props, childSlots); err != nil {
+			return err
+		}
 	}
 
 	// Generar llamada GoTpl
-	buf.WriteString(`{{template "` + componentName + `" dict `)
-	for k, v := range props {
-		fmt.Fprintf(buf, `"%s" "%s" `, k, v)
+	var dict bytes.Buffer
+	dict.WriteString(`dict`)
+	for _, p := range props {
+		arg := strconv.Quote(p.Val)
+		if p.Expr {
+			arg = "(" + p.Val + ")"
+		} else if def, ok := findPropDef(defs, p.Key); ok {
+			var err error
+			if arg, err = def.literalArg(p.Val); err != nil {
+				return fmt.Errorf("<%s>: %w", componentName, err)
+			}
+		}
+		fmt.Fprintf(&dict, ` %q %s`, p.Key, arg)
+	}
+	for _, s := range childSlots {
+		fmt.Fprintf(&dict, ` %q %s`, s[0], s[1])
+	}
+
+	args := dict.String()
+	if len(spreads) > 0 {
+		merged := fmt.Sprintf("(spread %s)", spreads[0])
+		for _, expr := range spreads[1:] {
+			merged = fmt.Sprintf("(merge %s (spread %s))", merged, expr)
+		}
+		args = fmt.Sprintf("merge %s (%s)", merged, args)
+	}
+	site := w.parser.addSite(w.logicalPath, componentName, pos)
+	if layout {
+		// El layout se ejecuta en línea (su <Flush/> envía de verdad) y encabeza
+		// la pila de los errores de la página (ver trace.go).
+		ref := w.parser.layouts[w.logicalPath]
+		ref.Site = site
+		w.parser.layouts[w.logicalPath] = ref
+		fmt.Fprintf(buf, `{{template %q (layoutScope $ (%s))}}`, componentName, args)
+		return nil
+	}
+
+	// component valida las props (y aplica defaults) y ejecuta el componente;
+	// si falla, el error lleva el id de la llamada (ver trace.go).
+	fmt.Fprintf(buf, `{{component %d %q (%s)}}`, site, componentName, args)
+	return nil
+}
+
+func findPropDef(defs []PropDef, name string) (PropDef, bool) {
+	for _, d := range defs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return PropDef{}, false
+}
+
+// checkRequiredProps exige las props requeridas sin default entre atributos y slots.
+func checkRequiredProps(componentName string, defs []PropDef, props []componentAttr, slots [][2]string) error {
+	given := make(map[string]struct{}, len(props)+len(slots))
+	for _, p := range props {
+		given[p.Key] = struct{}{}
+	}
+	for _, s := range slots {
+		given[s[0]] = struct{}{}
 	}
-	for k, v := range childSlots {
-		fmt.Fprintf(buf, `"%s" (template "%s" .) `, k, v)
+	for _, d := range defs {
+		if _, ok := given[d.Name]; d.Required && !d.HasDefault && !ok {
+			return fmt.Errorf("<%s>: missing required prop %q", componentName, d.Name)
+		}
 	}
-	buf.WriteString(`}}`)
 	return nil
 }
 
+// defineSlot registra el contenido de un slot como define propio y devuelve la
+// llamada (slot …) que lo entrega al componente. El define se ejecuta con el
+// contexto del llamador como punto; si el contenido usa $ o hay variables en
+// alcance, recibe además la raíz de la página y esas variables en un
+// slotScope, y las vuelve a declarar ($ incluido) antes del contenido.
+func (w *pageWalker) defineSlot(componentName, slotName, content string) string {
+	name := slotDefineName(w.logicalPath, componentName, slotName, w.counter)
+	w.counter++
+	call := fmt.Sprintf("(slot %q .)", name)
+	if vars := w.scopeVars(); len(vars) > 0 || strings.Contains(content, "$") {
+		var pre, args strings.Builder
+		pre.WriteString("{{$ = .Root}}")
+		for _, v := range vars {
+			fmt.Fprintf(&pre, `{{$%s := index .Vars %q}}`, v, v)
+			fmt.Fprintf(&args, ` %q $%s`, v, v)
+		}
+		content = fmt.Sprintf(`%s{{range .Dot}}%s{{end}}`, pre.String(), content)
+		call = fmt.Sprintf("(slot %q . $%s)", name, args.String())
+	}
+	w.slotDefs = append(w.slotDefs, fmt.Sprintf(`{{define "%s"}}%s{{end}}`, name, content))
+	return call
+}
+
+// scopeVars devuelve las variables en alcance, sin repetir.
+func (w *pageWalker) scopeVars() []string {
+	seen := make(map[string]struct{}, len(w.vars))
+	var out []string
+	for _, v := range w.vars {
+		if _, dup := seen[v]; !dup {
+			seen[v] = struct{}{}
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// actionPattern reconoce, en un bloque {{...}} escrito a mano, la palabra clave
+// que abre o cierra un bloque y las variables que declara.
+var actionPattern = regexp.MustCompile(`^{{-?\s*(?:(if|with|range|block|define|else|end)\b\s*(?:(?:if|with)\b\s*)?)?(?:\$(\w+)\s*(?:,\s*\$(\w+)\s*)?:=)?`)
+
+// trackAction sigue el alcance de las variables declaradas en bloques GoTpl de
+// la página: {{$x := …}} vale hasta el {{end}} del bloque en curso y
+// {{range $i, $u := …}} hasta su propio {{end}}. {{block}} y {{define}} no ven
+// las variables de afuera.
+func (w *pageWalker) trackAction(text string) {
+	m := actionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return
+	}
+	switch m[1] {
+	case "if", "with", "range":
+		w.scopes = append(w.scopes, w.vars)
+	case "block", "define":
+		w.scopes = append(w.scopes, w.vars)
+		w.vars = nil
+	case "end":
+		if n := len(w.scopes); n > 0 {
+			w.vars, w.scopes = w.scopes[n-1], w.scopes[:n-1]
+		}
+		return
+	}
+	for _, v := range m[2:] {
+		if v != "" {
+			w.vars = append(slices.Clip(w.vars), v)
+		}
+	}
+}
+
 func slotDefineName(logicalPath, component, slotName string, counter int) string {
 	return fmt.Sprintf("%s__%s__%s__%d", logicalPath, component, slotName, counter)
 }
@@ -1,7 +1,7 @@
 package teggo
 
 import (
-	"fmt"
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -78,19 +78,398 @@ func TestParseTagsToGoTpl_CardWithFooterSlotAndButton_FullRender(t *testing.T) {
 	}
 	os.WriteFile("rendered.html", []byte(rendered), 0644)
 
+	// El botón conserva los saltos de línea de su template alrededor de {{slot}}.
 	want := `<div class="card">
   <h2>Hola</h2>
   <section>
     contenido
   </section>
   <footer>
-    <button class="success">Guardar</button>
+    <button class="success">
+  Guardar
+</button>
   </footer>
 </div>`
 
-	fmt.Println(eng.componentRegistry)
 	if clean(rendered) != clean(want) {
 		t.Errorf("Button Component:\n--- Got ---\n%s\n--- Want ---\n%s\n", rendered, want)
 	}
 
 }
+
+func TestParseTagsToGoTpl_SpreadProps(t *testing.T) {
+	type user struct {
+		Name  string
+		Email string
+	}
+
+	files := map[string]string{
+		"components/UserCard.html": `{{tag UserCard}}<div class="{{.Class}}">{{.Name}} &lt;{{.Email}}&gt;</div>{{end}}`,
+		"pages/Home.html": `{{range $user := .Users}}<UserCard {...$user} Name="Override" Class="uc"></UserCard>{{end}}
+<UserCard {...  .Admin} Class="admin"></UserCard>`,
+	}
+
+	eng, err := NewEngineFromSource(files, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	err = eng.Render("pages.Home", map[string]interface{}{
+		"Users": []map[string]interface{}{
+			{"Name": "Alice", "Email": "alice@teggo.com", "Class": "ignored"},
+		},
+		"Admin": &user{Name: "Root", Email: "root@teggo.com"},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+
+	want := `<div class="uc">Override &lt;alice@teggo.com&gt;</div>
+<div class="admin">Root &lt;root@teggo.com&gt;</div>`
+	if clean(out.String()) != clean(want) {
+		t.Errorf("Spread props:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+}
+
+func TestParseTagsToGoTpl_ControlFlowTags(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html": `<If cond=".IsAdmin">admin<ElseIf cond='eq .Role "editor"'>editor</ElseIf><Else>guest</Else></If>
+<ul><For each=".Users" as="user" index="i"><li><Card>{{$i}}:{{$user.Name}}</Card></li><Empty><li>none</li></Empty></For></ul>`,
+	}
+
+	eng, err := NewEngineFromSource(files, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	cases := []struct {
+		data map[string]interface{}
+		want string
+	}{
+		{
+			data: map[string]interface{}{
+				"IsAdmin": true,
+				"Users":   []map[string]interface{}{{"Name": "Alice"}, {"Name": "Bob"}},
+			},
+			want: `admin
+<ul><li><div class="card">0:Alice</div></li><li><div class="card">1:Bob</div></li></ul>`,
+		},
+		{
+			data: map[string]interface{}{"Role": "editor"},
+			want: `editor
+<ul><li>none</li></ul>`,
+		},
+		{
+			data: map[string]interface{}{"Role": "viewer"},
+			want: `guest
+<ul><li>none</li></ul>`,
+		},
+	}
+
+	for _, tc := range cases {
+		var out strings.Builder
+		if err := eng.Render("pages.Home", tc.data, &out); err != nil {
+			t.Fatalf("Engine failed to render: %v", err)
+		}
+		if clean(out.String()) != clean(tc.want) {
+			t.Errorf("Control flow:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), tc.want)
+		}
+	}
+}
+
+func TestParseTagsToGoTpl_SlotKeepsPageRootUnderFor(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html":      `<For each=".Users" as="u"><Card>{{$.Site}}-{{$u.Name}}</Card></For>`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var out strings.Builder
+	data := map[string]interface{}{"Site": "acme", "Users": []map[string]interface{}{{"Name": "Ana"}, {"Name": "Luis"}}}
+	if err := eng.Render("pages.Home", data, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	want := `<div class="card">acme-Ana</div><div class="card">acme-Luis</div>`
+	if got := clean(out.String()); got != want {
+		t.Errorf("Rendered output mismatch\n--- Got ---\n%s\n--- Want ---\n%s", got, want)
+	}
+}
+
+func TestParseTagsToGoTpl_SlotSeesVariablesInScope(t *testing.T) {
+	card := `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`
+	data := map[string]interface{}{"Site": "acme", "Users": []string{"ana", "luis"}}
+	cases := []struct {
+		name, page, want string
+	}{
+		{
+			name: "range variable",
+			page: `{{range $u := .Users}}<Card>{{$u}}</Card>{{end}}`,
+			want: `<div class="card">ana</div><div class="card">luis</div>`,
+		},
+		{
+			name: "range index and value",
+			page: `{{range $i, $u := .Users}}<Card>{{$i}}={{$u}}</Card>{{end}}`,
+			want: `<div class="card">0=ana</div><div class="card">1=luis</div>`,
+		},
+		{
+			name: "declared variable",
+			page: `{{$x := "v"}}<Card>{{$x}}</Card>`,
+			want: `<div class="card">v</div>`,
+		},
+		{
+			name: "page root under For",
+			page: `<For each=".Users"><Card>{{$.Site}}</Card></For>`,
+			want: `<div class="card">acme</div><div class="card">acme</div>`,
+		},
+		{
+			name: "nested slots",
+			page: `{{with $s := .Site}}<For each="$.Users" as="u"><Card><Card>{{$s}}/{{$u}}/{{$.Site}}</Card></Card></For>{{end}}`,
+			want: `<div class="card"><div class="card">acme/ana/acme</div></div><div class="card"><div class="card">acme/luis/acme</div></div>`,
+		},
+		{
+			name: "variables out of scope are not forwarded",
+			page: `{{if .Site}}{{$y := 1}}{{$y}}{{end}}<Card>{{if true}}{{$z := 2}}{{$z}}{{end}}</Card><Card>ok</Card>`,
+			want: `1<div class="card">2</div><div class="card">ok</div>`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{"components/Card.html": card, "pages/Home.html": tc.page}
+			eng, err := NewEngineFromSource(files, false)
+			if err != nil {
+				t.Fatalf("Engine failed to parse generated templates: %v", err)
+			}
+			var out strings.Builder
+			if err := eng.Render("pages.Home", data, &out); err != nil {
+				t.Fatalf("Engine failed to render: %v", err)
+			}
+			if got := clean(out.String()); got != tc.want {
+				t.Errorf("Rendered output mismatch\n--- Got ---\n%s\n--- Want ---\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTagsToGoTpl_PropSchema(t *testing.T) {
+	badge := `{{tag Badge}}
+{{/* props: Label string!, Count int = 1, Kind string = "info, default", Active bool */}}
+<span class="{{.Kind}}">{{.Label}} {{printf "%d" .Count}}{{if .Active}}!{{end}}</span>{{end}}`
+
+	eng, err := New(WithFiles(map[string]string{
+		"components/Badge.html": badge,
+		"pages/Home.html":       `<Badge Label="Inbox" Count="3" Active="true"></Badge> <Badge {...$}></Badge>`,
+	}), WithDebug(true), WithErrorPolicy(PolicyStrict))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	if defs := eng.Props("Badge"); len(defs) != 4 || !defs[0].Required || defs[2].Default != "info, default" {
+		t.Errorf("unexpected prop schema: %+v", defs)
+	}
+
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]interface{}{"Label": "Spread"}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	want := `<span class="info, default">Inbox 3!</span> <span class="info, default">Spread 1</span>`
+	if strings.Join(strings.Fields(out.String()), " ") != want {
+		t.Errorf("Prop defaults:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+
+	// Validación en render: valores dinámicos de tipo incorrecto o faltantes.
+	for _, data := range []map[string]interface{}{
+		{"Label": "x", "Count": "3"},
+		{},
+	} {
+		if err := eng.Render("pages.Home", data, &strings.Builder{}); err == nil {
+			t.Errorf("expected render error for %v", data)
+		}
+	}
+
+	// Validación en compilación: literales.
+	for _, page := range []string{
+		`<Badge Count="3"></Badge>`,
+		`<Badge Label="x" Count="tres"></Badge>`,
+	} {
+		_, err := NewEngineFromSource(map[string]string{
+			"components/Badge.html": badge,
+			"pages/Home.html":       page,
+		}, true)
+		if err == nil {
+			t.Errorf("expected compile error for %s", page)
+		}
+	}
+}
+
+func TestParseTagsToGoTpl_AttributeExpressions(t *testing.T) {
+	files := map[string]string{
+		"components/Item.html": `{{tag Item}}<li>{{.Title}}|{{printf "%T" .Count}}|{{if .Disabled}}off{{else}}on{{end}}|{{if .Hidden}}hidden{{end}}</li>{{end}}`,
+		"pages/Home.html":      `<ul><Item :Title=".User.Name" Count={{len .Items}} Disabled :Hidden="false"></Item></ul>`,
+	}
+
+	eng, err := NewEngineFromSource(files, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	err = eng.Render("pages.Home", map[string]interface{}{
+		"User":  map[string]interface{}{"Name": "Alice"},
+		"Items": []int{1, 2, 3},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+
+	want := `<ul><li>Alice|int|off|</li></ul>`
+	if clean(out.String()) != want {
+		t.Errorf("Attribute expressions:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+}
+
+func TestParseTagsToGoTpl_SelfClosingTags(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html":   `{{tag Card}}<div class="card"><h2>{{.Title}}</h2>{{slot}}<footer>{{slot name="Footer"}}</footer></div>{{end}}`,
+		"components/Button.html": `{{tag MyButton}}{{/* props: Class string!, Label string = "OK" */}}<button class="{{.Class}}">{{.Label}}</button>{{end}}`,
+		"components/Icon.html":   `{{tag Icon}}<i class="{{.Name}}"></i>{{end}}`,
+		"pages/Home.html": `<Card Title="Hola">
+  <MyButton Class="a" />
+  <p>texto</p>
+  <MyButton Class="b" Label="Guardar"/>
+  <slot name="Footer"><Icon Name=x/>fin</slot>
+</Card>
+<Card Title="Vacía" />
+<For each=".Users" as="user"><UserCard {...$user}/></For>`,
+		"components/UserCard.html": `{{tag UserCard}}<span>{{.Name}}</span>{{end}}`,
+	}
+
+	eng, err := NewEngineFromSource(files, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	err = eng.Render("pages.Home", map[string]interface{}{
+		"Users": []map[string]interface{}{{"Name": "Ana"}, {"Name": "Bob"}},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+
+	want := `<div class="card"><h2>Hola</h2><button class="a">OK</button>
+  <p>texto</p>
+  <button class="b">Guardar</button><footer><i class="x"></i>fin</footer></div>
+<div class="card"><h2>Vacía</h2><footer></footer></div>
+<span>Ana</span><span>Bob</span>`
+	if clean(out.String()) != clean(want) {
+		t.Errorf("Self-closing tags:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+
+	// Las props requeridas se validan igual en la forma corta.
+	_, err = NewEngineFromSource(map[string]string{
+		"components/Button.html": files["components/Button.html"],
+		"pages/Home.html":        `<p><MyButton Label="x"/></p>`,
+	}, true)
+	if err == nil || !strings.Contains(err.Error(), `missing required prop "Class"`) {
+		t.Errorf("expected missing prop error, got %v", err)
+	}
+}
+
+func TestParseTagsToGoTpl_PlainMarkupRoundTrip(t *testing.T) {
+	page := `<!DOCTYPE html>
+<!-- cabecera -->
+<!--[if IE]><p class=ie>IE</p><![endif]-->
+<Section data-Id='{{.ID}}' ShowActions hidden=until-found>
+  <svg viewBox="0 0 10 10"><linearGradient gradientUnits="userSpaceOnUse"/></svg>
+  <br><img src="/a.png" alt='dice "hola"'>
+  <p title="<Badge> &amp; más">a &lt; b &#x27;</p>
+  <Badge Text="nuevo"/>
+</Section>`
+
+	eng, err := NewEngineFromSource(map[string]string{
+		"components/Badge.html": `{{tag Badge}}<b>{{.Text}}</b>{{end}}`,
+		"pages/Home.html":       page,
+	}, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]interface{}{"ID": 7}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+
+	// Todo lo que no es un componente sale tal como se escribió.
+	want := strings.NewReplacer(`{{.ID}}`, `7`, `<Badge Text="nuevo"/>`, `<b>nuevo</b>`).Replace(page)
+	if clean(out.String()) != want {
+		t.Errorf("Round trip:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+
+	// Solo un comentario bien formado se emite sin escapar.
+	if got := HTMLComment("<!-- a --><script>x</script><!-- b -->"); strings.Contains(string(got), "<script>") {
+		t.Errorf("HTMLComment must escape non-comments: %s", got)
+	}
+}
+
+func TestParseTagsToGoTpl_MustacheInAttributes(t *testing.T) {
+	files := map[string]string{
+		"components/Item.html": `{{tag Item}}<li>{{.Title}}|{{printf "%T" .Count}}|{{.Href}}</li>{{end}}`,
+		"pages/Home.html": `<ul><For each="{{.Users}}" as="u"><a href="/u/{{$u.ID}}" class="{{if $u.Admin}}admin{{end}}">` +
+			`<Item Title="Hola {{$u.Name}} {{/* nombre */}}100%" Count="{{$u.ID}}" Href='/u/{{$u.ID}}?q={{printf "%s" $u.Name}}'/>` +
+			`</a></For></ul>`,
+	}
+
+	eng, err := NewEngineFromSource(files, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	err = eng.Render("pages.Home", map[string]interface{}{
+		"Users": []map[string]interface{}{
+			{"ID": 1, "Name": "Ana", "Admin": true},
+			{"ID": 2, "Name": "Bob"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+
+	want := `<ul><a href="/u/1" class="admin"><li>Hola Ana 100%|int|/u/1?q=Ana</li></a>` +
+		`<a href="/u/2" class=""><li>Hola Bob 100%|int|/u/2?q=Bob</li></a></ul>`
+	if clean(out.String()) != want {
+		t.Errorf("Mustache in attributes:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+
+	// Las acciones de control no son pipelines: error de compilación en el tag.
+	_, err = NewEngineFromSource(map[string]string{
+		"components/Item.html": files["components/Item.html"],
+		"pages/Home.html":      "<ul>\n  <Item Title=\"{{if .X}}a{{end}}\"/></ul>",
+	}, true)
+	var ce *CompileError
+	if !errors.As(err, &ce) || ce.Line != 2 || !strings.Contains(ce.Error(), "not allowed in a component attribute") {
+		t.Errorf("expected compile error at line 2, got %v", err)
+	}
+}
+
+func TestParseTagsToGoTpl_FloatPropLiteral(t *testing.T) {
+	eng, err := NewEngineFromSource(map[string]string{
+		"components/Price.html": `{{tag Price}}{{/* props: V float, W float = 2 */}}<b>{{printf "%.2f|%.2f" .V .W}}</b>{{end}}`,
+		"pages/Home.html":       `<Price V="3"/> <Price V="1e3" W="0.5"/>`,
+	}, true)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	if err := eng.Render("pages.Home", nil, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	// Un literal entero de una prop float llega como float64, no como int.
+	if want := `<b>3.00|2.00</b> <b>1000.00|0.50</b>`; out.String() != want {
+		t.Errorf("Float literal:\n--- Got ---\n%s\n--- Want ---\n%s\n", out.String(), want)
+	}
+}
@@ -0,0 +1,214 @@
+// props.go
+// Paquete teggo — Declaración, validación y defaults de props de componentes.
+// -----------------------------------------------------------------------------
+// Un componente declara sus props en un comentario GoTpl:
+//
+//	{{/* props: Title string!, Count int = 3, Label string = "Guardar", Slot any */}}
+//
+// «!» marca la prop como requerida y «= valor» define su default. Tipos válidos:
+// any, string, int, float, bool, html, map, slice.
+
+package teggo
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	propsPattern   = regexp.MustCompile(`(?s){{-?\s*/\*\s*props:\s*(.*?)\s*\*/\s*-?}}`)
+	propDefPattern = regexp.MustCompile(`(?s)^(\w+)\s+(\w+)\s*(!?)\s*(?:=\s*(.+))?$`)
+)
+
+// PropDef describe una prop declarada por un componente.
+type PropDef struct {
+	Name       string
+	Type       string
+	Required   bool
+	Default    interface{}
+	HasDefault bool
+}
+
+var propTypes = map[string]struct{}{
+	"any": {}, "string": {}, "int": {}, "float": {}, "bool": {}, "html": {}, "map": {}, "slice": {},
+}
+
+// ParsePropSchema lee la declaración {{/* props: ... */}} de un componente.
+// Devuelve nil si el componente no declara props.
+func ParsePropSchema(source string) ([]PropDef, error) {
+	match := propsPattern.FindStringSubmatch(source)
+	if match == nil {
+		return nil, nil
+	}
+	var defs []PropDef
+	for _, item := range splitPropList(match[1]) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		m := propDefPattern.FindStringSubmatch(item)
+		if m == nil {
+			return nil, fmt.Errorf("invalid prop declaration %q", item)
+		}
+		def := PropDef{Name: m[1], Type: m[2], Required: m[3] == "!"}
+		if _, ok := propTypes[def.Type]; !ok {
+			return nil, fmt.Errorf("prop %s: unknown type %q", def.Name, def.Type)
+		}
+		if m[4] != "" {
+			v, err := parsePropLiteral(def.Type, strings.TrimSpace(m[4]))
+			if err != nil {
+				return nil, fmt.Errorf("prop %s: invalid default: %w", def.Name, err)
+			}
+			def.Default, def.HasDefault = v, true
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// splitPropList separa la lista por comas que no estén dentro de comillas.
+func splitPropList(s string) []string {
+	var out []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == ',':
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// parsePropLiteral convierte un literal (default o atributo) al tipo declarado.
+// Los strings pueden venir entre comillas; para any se infiere el tipo.
+func parsePropLiteral(typ, raw string) (interface{}, error) {
+	if unq, err := strconv.Unquote(raw); err == nil {
+		raw = unq
+		if typ == "any" {
+			return raw, nil
+		}
+	}
+	switch typ {
+	case "string":
+		return raw, nil
+	case "html":
+		return template.HTML(raw), nil
+	case "int":
+		return strconv.Atoi(raw)
+	case "float":
+		return strconv.ParseFloat(raw, 64)
+	case "bool":
+		return strconv.ParseBool(raw)
+	case "any":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, nil
+		}
+		if i, err := strconv.Atoi(raw); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f, nil
+		}
+		return raw, nil
+	}
+	return nil, fmt.Errorf("type %s has no literal form", typ)
+}
+
+// literalArg valida un atributo literal contra la prop declarada y devuelve el
+// argumento GoTpl tipado (3, true, "texto").
+func (d PropDef) literalArg(val string) (string, error) {
+	switch d.Type {
+	case "string", "html", "any":
+		return strconv.Quote(val), nil
+	}
+	v, err := parsePropLiteral(d.Type, val)
+	if f, ok := v.(float64); ok && err == nil {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			err = fmt.Errorf("not finite")
+		} else {
+			// Con punto decimal, para que GoTpl lo lea como float y no como int.
+			lit := strconv.FormatFloat(f, 'f', -1, 64)
+			if !strings.Contains(lit, ".") {
+				lit += ".0"
+			}
+			return lit, nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("prop %s: %q is not a valid %s", d.Name, val, d.Type)
+	}
+	return fmt.Sprint(v), nil
+}
+
+// accepts verifica en tiempo de render que v sea compatible con el tipo declarado.
+func (d PropDef) accepts(v interface{}) bool {
+	if d.Type == "any" {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch d.Type {
+	case "string":
+		return rv.Kind() == reflect.String
+	case "html":
+		_, isSlot := v.(Slot)
+		return rv.Kind() == reflect.String || isSlot
+	case "bool":
+		return rv.Kind() == reflect.Bool
+	case "int":
+		return isIntKind(rv.Kind())
+	case "float":
+		return isIntKind(rv.Kind()) || rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64
+	case "map":
+		return rv.Kind() == reflect.Map
+	case "slice":
+		return rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array
+	}
+	return false
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// applyProps valida las props de una invocación en tiempo de render e inyecta
+// los defaults. Las props sin declarar se pasan tal cual.
+func applyProps(component string, defs []PropDef, props map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(props)+len(defs))
+	for k, v := range props {
+		out[k] = v
+	}
+	for _, d := range defs {
+		v, ok := out[d.Name]
+		if !ok || v == nil {
+			switch {
+			case d.HasDefault:
+				out[d.Name] = d.Default
+			case d.Required:
+				return nil, fmt.Errorf("<%s>: missing required prop %q", component, d.Name)
+			}
+			continue
+		}
+		if !d.accepts(v) {
+			return nil, fmt.Errorf("<%s>: prop %s expects %s, got %T", component, d.Name, d.Type, v)
+		}
+	}
+	return out, nil
+}
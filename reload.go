@@ -0,0 +1,190 @@
+// reload.go
+// Paquete teggo — Hot reload de templates para desarrollo.
+// -----------------------------------------------------------------------------
+// ReloadingEngine vigila (por polling, sin notificadores del sistema) los
+// directorios de templates y recompila cuando cambian. Si la recompilación
+// falla, sigue sirviendo el último set válido y reporta el error.
+
+package teggo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReloadingEngine envuelve un Engine que se reemplaza de forma atómica en cada recarga.
+type ReloadingEngine struct {
+	opts  Options
+	debug bool
+
+	current atomic.Pointer[Engine]
+
+	mu          sync.Mutex
+	fingerprint string
+	lastErr     error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewReloadingEngine compila los templates de dirs (filtrados por sufijos, como
+// Discover) y revisa cambios cada interval. Los nombres lógicos son relativos a
+// cada directorio: «dir/pages/Home.html» → «pages.Home».
+func NewReloadingEngine(dirs []string, interval time.Duration, debug bool, suffixes ...string) (*ReloadingEngine, error) {
+	opts := Options{Suffixes: suffixes, Debug: debug}
+	for _, dir := range dirs {
+		opts.Roots = append(opts.Roots, Root{Dir: dir})
+	}
+	return NewReloadingEngineWithOptions(opts, interval)
+}
+
+// NewReloadingEngineWithOptions es NewReloadingEngine con raíces, namespaces y
+// nombres configurables. Las raíces sobre un fs.FS se compilan pero no se vigilan
+// por fecha (un embed.FS no cambia); solo Reload las vuelve a leer.
+func NewReloadingEngineWithOptions(opts Options, interval time.Duration) (*ReloadingEngine, error) {
+	r := &ReloadingEngine{
+		opts:  opts,
+		debug: opts.Debug,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	go r.watch(interval)
+	return r, nil
+}
+
+// Engine retorna el set compilado vigente.
+func (r *ReloadingEngine) Engine() *Engine {
+	return r.current.Load()
+}
+
+// Render ejecuta el template indicado sobre el set vigente.
+func (r *ReloadingEngine) Render(name string, data any, w io.Writer) error {
+	return r.Engine().Render(name, data, w)
+}
+
+// RenderStream ejecuta el template indicado en streaming sobre el set vigente.
+func (r *ReloadingEngine) RenderStream(name string, data any, w io.Writer) error {
+	return r.Engine().RenderStream(name, data, w)
+}
+
+// RenderFragment ejecuta un fragmento de página sobre el set vigente.
+func (r *ReloadingEngine) RenderFragment(page, fragment string, data any, w io.Writer) error {
+	return r.Engine().RenderFragment(page, fragment, data, w)
+}
+
+// LastError retorna el error de la última recarga, o nil si compiló bien.
+func (r *ReloadingEngine) LastError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastErr
+}
+
+// Reload recompila de inmediato. Si falla, conserva el set anterior.
+func (r *ReloadingEngine) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fp, err := r.scan()
+	if err != nil {
+		r.lastErr = err
+		return err
+	}
+	r.fingerprint = fp
+	return r.compile()
+}
+
+// Close detiene el watcher.
+func (r *ReloadingEngine) Close() {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+}
+
+func (r *ReloadingEngine) watch(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.reloadIfChanged()
+		}
+	}
+}
+
+func (r *ReloadingEngine) reloadIfChanged() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fp, err := r.scan()
+	if err != nil {
+		// Una raíz borrada o ilegible: se sigue sirviendo el último set. Se
+		// reporta una vez y, al volver, se recompila aunque nada haya cambiado.
+		if r.lastErr == nil || r.lastErr.Error() != err.Error() {
+			r.opts.logger().Printf("Teggo ▶ reload: %v", err)
+		}
+		r.lastErr = err
+		r.fingerprint = ""
+		return
+	}
+	if fp == r.fingerprint {
+		return
+	}
+	r.fingerprint = fp
+	if err := r.compile(); err != nil {
+		r.opts.logger().Printf("Teggo ▶ reload: %v", err)
+	} else if r.debug {
+		r.opts.logger().Printf("🔄 Templates recargados.")
+	}
+}
+
+// compile lee, transpila y publica un nuevo Engine. Requiere r.mu.
+func (r *ReloadingEngine) compile() error {
+	eng, err := NewEngineWithOptions(r.opts)
+	if err != nil {
+		r.lastErr = err
+		return err
+	}
+	r.current.Store(eng)
+	r.lastErr = nil
+	return nil
+}
+
+// scan produce una huella de los archivos vigilados (ruta, tamaño y fecha).
+func (r *ReloadingEngine) scan() (string, error) {
+	var entries []string
+	for _, root := range r.opts.Roots {
+		if root.FS != nil {
+			continue
+		}
+		if _, err := os.Stat(root.Dir); err != nil {
+			return "", err
+		}
+		for _, path := range Discover(root.Dir, r.opts.Suffixes...) {
+			info, err := os.Stat(path)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return "", err
+			}
+			entries = append(entries, fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()))
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n"), nil
+}
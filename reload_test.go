@@ -0,0 +1,126 @@
+package teggo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadingEngine_SwapsAndKeepsLastGoodSet(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "pages", "Home.html")
+	if err := os.MkdirAll(filepath.Dir(home), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(src string) {
+		if err := os.WriteFile(home, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	render := func(r *ReloadingEngine) string {
+		var out strings.Builder
+		if err := r.Render("pages.Home", nil, &out); err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		return clean(out.String())
+	}
+	waitFor := func(cond func() bool) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for reload")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	write(`<p>v1</p>`)
+	r, err := NewReloadingEngine([]string{dir}, 10*time.Millisecond, false, "*.html")
+	if err != nil {
+		t.Fatalf("NewReloadingEngine: %v", err)
+	}
+	defer r.Close()
+	if got := render(r); got != "<p>v1</p>" {
+		t.Fatalf("got %q", got)
+	}
+
+	write(`<p>v2, reloaded</p>`)
+	waitFor(func() bool { return render(r) == "<p>v2, reloaded</p>" })
+
+	// Un error de compilación conserva el último set válido.
+	write(`<p>{{if .Broken}}</p>`)
+	waitFor(func() bool { return r.LastError() != nil })
+	if got := render(r); got != "<p>v2, reloaded</p>" {
+		t.Fatalf("expected last good set, got %q", got)
+	}
+
+	write(`<p>v3</p>`)
+	waitFor(func() bool { return r.LastError() == nil && render(r) == "<p>v3</p>" })
+}
+
+// syncLogger es un Logger seguro para el watcher, que escribe en otra goroutine.
+type syncLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *syncLogger) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *syncLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...)
+}
+
+func TestReloadingEngine_ReportsMissingRoot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "views")
+	if err := os.MkdirAll(filepath.Join(dir, "pages"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pages", "Home.html"), []byte(`<p>v1</p>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := &syncLogger{}
+	r, err := NewReloadingEngineWithOptions(Options{Roots: []Root{{Dir: dir}}, Suffixes: []string{"*.html"}, Logger: logger}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewReloadingEngine: %v", err)
+	}
+	defer r.Close()
+	waitFor := func(cond func() bool) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for reload")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Sin la raíz se sigue sirviendo el último set y el error queda a la vista.
+	moved := dir + ".bak"
+	if err := os.Rename(dir, moved); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return r.LastError() != nil })
+	time.Sleep(50 * time.Millisecond)
+	if lines := logger.Lines(); len(lines) != 1 || !strings.Contains(lines[0], "reload") {
+		t.Errorf("expected the scan error logged once, got %q", lines)
+	}
+	var out strings.Builder
+	if err := r.Render("pages.Home", nil, &out); err != nil || clean(out.String()) != "<p>v1</p>" {
+		t.Errorf("expected last good set, got %q (%v)", out.String(), err)
+	}
+
+	if err := os.Rename(moved, dir); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return r.LastError() == nil })
+}
@@ -0,0 +1,162 @@
+// stream.go
+// Paquete teggo — Render en streaming con puntos de flush explícitos.
+// -----------------------------------------------------------------------------
+// Render arma la salida en memoria cuando hay assets que inyectar. RenderStream
+// escribe directo en el destino a medida que se ejecuta el template y, en cada
+// <Flush/> de la página ({{flush}}), envía lo acumulado al cliente llamando a
+// Flush (http.Flusher o un writer con Flush() error, como bufio.Writer).
+//
+// El contenido de los slots se ejecuta en memoria y se imprime completo, así
+// que <Flush/> no se admite dentro de un slot ni en el cuerpo de una página
+// con layout (error de compilación): el flush va en la página o en el layout.
+//
+// Como la salida ya salió cuando se llega a un placeholder, en streaming:
+//   - {{styles}} y {{head}} emiten los assets de todos los componentes;
+//   - {{scripts}} emite los de los componentes renderizados hasta ese punto,
+//     por eso debe ir al final del documento.
+
+package teggo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RenderStream ejecuta el template indicado escribiendo directo en w. Si falla a
+// mitad de camino, lo ya escrito queda en w y se devuelve el error.
+func (e *Engine) RenderStream(name string, data any, w io.Writer) error {
+	sw := &streamWriter{engine: e, w: w, seen: make(map[string]struct{})}
+	err := e.base.ExecuteTemplate(sw, name, data)
+	if werr := sw.finish(); err == nil {
+		err = werr
+	} else {
+		err = e.renderError(name, err)
+	}
+	return err
+}
+
+// flusherWithError cubre writers con Flush() error, como bufio.Writer.
+type flusherWithError interface {
+	Flush() error
+}
+
+// streamWriter resuelve las marcas de la salida (ver assets.go) a medida que
+// llegan. Una marca partida entre dos Write queda pendiente hasta completarse.
+type streamWriter struct {
+	engine  *Engine
+	w       io.Writer
+	pending []byte
+	used    []string
+	seen    map[string]struct{}
+	scripts map[string]struct{} // Scripts ya emitidos
+	noFlush bool                // Render: las marcas de flush se descartan
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+	for {
+		start := bytes.Index(s.pending, []byte(assetMark))
+		if start < 0 {
+			// Un prefijo de marca al final puede completarse en el próximo Write.
+			keep := bytes.LastIndexByte(s.pending, assetMark[0])
+			if keep < 0 || !bytes.HasPrefix([]byte(assetMark), s.pending[keep:]) {
+				keep = len(s.pending)
+			}
+			if err := s.emit(s.pending[:keep]); err != nil {
+				return 0, err
+			}
+			s.pending = append(s.pending[:0], s.pending[keep:]...)
+			return len(p), nil
+		}
+		end := bytes.Index(s.pending[start+len(assetMark):], []byte(assetMark))
+		if end < 0 {
+			// Marca incompleta: emitir lo previo y esperar el resto.
+			if err := s.emit(s.pending[:start]); err != nil {
+				return 0, err
+			}
+			s.pending = append(s.pending[:0], s.pending[start:]...)
+			return len(p), nil
+		}
+		kind := string(s.pending[start+len(assetMark) : start+len(assetMark)+end])
+		if err := s.emit(s.pending[:start]); err != nil {
+			return 0, err
+		}
+		s.pending = append(s.pending[:0], s.pending[start+2*len(assetMark)+end:]...)
+		if err := s.mark(kind); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (s *streamWriter) emit(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	_, err := s.w.Write(b)
+	return err
+}
+
+// mark resuelve una marca: uso de componente, placeholder de assets o flush.
+func (s *streamWriter) mark(kind string) error {
+	e := s.engine
+	if name, ok := strings.CutPrefix(kind, "use:"); ok {
+		if _, dup := s.seen[name]; !dup {
+			s.seen[name] = struct{}{}
+			s.used = append(s.used, name)
+		}
+		return nil
+	}
+	switch kind {
+	case "styles":
+		return s.emit([]byte(styleTag(e.Styles())))
+	case "head":
+		return s.emit([]byte(strings.Join(uniqueAssets(e.parser.head, sortedKeys(e.parser.head)), "\n")))
+	case "scripts":
+		if s.scripts == nil {
+			s.scripts = make(map[string]struct{})
+		}
+		var out []string
+		for _, script := range uniqueAssets(e.parser.scripts, s.used) {
+			if _, done := s.scripts[script]; !done {
+				s.scripts[script] = struct{}{}
+				out = append(out, script)
+			}
+		}
+		return s.emit([]byte(strings.Join(out, "\n")))
+	case "flush":
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *streamWriter) flush() error {
+	if s.noFlush {
+		return nil
+	}
+	switch f := s.w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case flusherWithError:
+		return f.Flush()
+	}
+	return nil
+}
+
+// finish escribe lo pendiente (un texto que parecía inicio de marca).
+func (s *streamWriter) finish() error {
+	err := s.emit(s.pending)
+	s.pending = nil
+	return err
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,123 @@
+package teggo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// flushRecorder guarda lo escrito hasta cada Flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushes []string
+}
+
+func (f *flushRecorder) Flush() { f.flushes = append(f.flushes, f.String()) }
+
+func TestRenderStream_FlushesAtFlushTags(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}<script once src="/card.js"></script><div class="card">{{slot}}</div>{{end}}`,
+		"pages/Home.html": `<header>Dashboard</header><Flush/>
+<For each=".Items" as="item"><Card>{{$item}}</Card></For>
+<Flush/>{{scripts}}`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	data := map[string]interface{}{"Items": []string{"a", "b"}}
+
+	var rec flushRecorder
+	if err := eng.RenderStream("pages.Home", data, &rec); err != nil {
+		t.Fatalf("RenderStream failed: %v", err)
+	}
+	if len(rec.flushes) != 2 {
+		t.Fatalf("expected 2 flushes, got %d", len(rec.flushes))
+	}
+	if first := rec.flushes[0]; !strings.Contains(first, "Dashboard") || strings.Contains(first, "card") {
+		t.Errorf("first flush should carry only the header: %q", first)
+	}
+	if second := rec.flushes[1]; strings.Count(second, `class="card"`) != 2 {
+		t.Errorf("second flush should carry the cards: %q", second)
+	}
+	streamed := rec.String()
+	if strings.Contains(streamed, "teggo:") || strings.Count(streamed, `<script src="/card.js"></script>`) != 1 {
+		t.Errorf("expected marks resolved and the script emitted once:\n%q", streamed)
+	}
+
+	// Render produce lo mismo, sin marcas.
+	var out strings.Builder
+	if err := eng.Render("pages.Home", data, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if out.String() != streamed {
+		t.Errorf("Render and RenderStream differ\n--- Render ---\n%q\n--- Stream ---\n%q", out.String(), streamed)
+	}
+}
+
+func TestRenderStream_FlushInLayout(t *testing.T) {
+	files := map[string]string{
+		"layouts/Base.html": `<head><title>{{.Page.Title}}</title></head><Flush/><body>{{slot}}</body>`,
+		"pages/Home.html":   `{{layout "layouts.Base"}}<p>contenido</p>`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var rec flushRecorder
+	if err := eng.RenderStream("pages.Home", map[string]string{"Title": "Inicio"}, &rec); err != nil {
+		t.Fatalf("RenderStream failed: %v", err)
+	}
+	if len(rec.flushes) != 1 || rec.flushes[0] != "<head><title>Inicio</title></head>" {
+		t.Errorf("expected the head flushed before the page body, got %q", rec.flushes)
+	}
+}
+
+func TestParse_FlushInsideSlotRejected(t *testing.T) {
+	cases := map[string]map[string]string{
+		"component slot": {
+			"pages/Home.html": `<Card><p>a</p><Flush/></Card>`,
+		},
+		"named slot": {
+			"pages/Home.html": `<Card><slot name="Footer">{{flush}}</slot></Card>`,
+		},
+		"page with layout": {
+			"layouts/Base.html": `<body>{{slot}}</body>`,
+			"pages/Home.html":   `{{layout "layouts.Base"}}<p>a</p><Flush/><p>b</p>`,
+		},
+	}
+	for name, files := range cases {
+		t.Run(name, func(t *testing.T) {
+			files["components/Card.html"] = `{{tag Card}}<div>{{slot}}{{slot name="Footer"}}</div>{{end}}`
+			_, err := NewEngineFromSource(files, false)
+			var ce *CompileError
+			if !errors.As(err, &ce) || !errors.Is(err, errFlushInSlot) {
+				t.Fatalf("expected a CompileError for flush inside a slot, got %v", err)
+			}
+			if ce.File != "pages/Home.html" || ce.Line != 1 {
+				t.Errorf("expected the error at pages/Home.html:1, got %s:%d", ce.File, ce.Line)
+			}
+		})
+	}
+}
+
+func TestStreamWriter_MarkSplitAcrossWrites(t *testing.T) {
+	eng, err := NewEngineFromSource(map[string]string{"pages/Home.html": `<p>x</p>`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec flushRecorder
+	sw := &streamWriter{engine: eng, w: &rec, seen: make(map[string]struct{})}
+	// Cortes dentro del delimitador (multibyte), del nombre de la marca y de un
+	// prefijo de marca que queda pendiente al final.
+	stream := "<p>a</p>" + assetMark + "flush" + assetMark + "<p>b</p>" + markDelim
+	for _, cut := range [][2]int{{0, 10}, {10, 19}, {19, 23}, {23, 40}, {40, len(stream)}} {
+		sw.Write([]byte(stream[cut[0]:cut[1]]))
+	}
+	sw.finish()
+	if len(rec.flushes) != 1 || rec.flushes[0] != "<p>a</p>" || rec.String() != "<p>a</p><p>b</p>"+markDelim {
+		t.Errorf("unexpected stream: %q (flushes %q)", rec.String(), rec.flushes)
+	}
+}
@@ -0,0 +1,319 @@
+// styles.go
+// Paquete teggo — CSS con alcance por componente.
+// -----------------------------------------------------------------------------
+// Un componente puede declarar un bloque <style scoped>. El engine lo extrae,
+// reescribe sus selectores con un atributo propio del componente (data-t-xxxx),
+// agrega ese atributo a los elementos raíz del componente y sirve el CSS solo en
+// las páginas que efectivamente renderizaron el componente ({{styles}}).
+//
+// Los selectores quedan acotados al componente y sus descendientes:
+//
+//	.card h2 { … }  ->  [data-t-1a2b3c4d] .card h2, .card[data-t-1a2b3c4d] h2 { … }
+
+package teggo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+var scopedStylePattern = regexp.MustCompile(`(?is)<style\s+scoped\s*>(.*?)</style>`)
+
+// scopeAttr es el atributo que identifica los elementos raíz de un componente.
+func scopeAttr(component string) string {
+	h := fnv.New32a()
+	h.Write([]byte(component))
+	return fmt.Sprintf("data-t-%08x", h.Sum32())
+}
+
+// extractScopedStyle quita los bloques <style scoped> de la fuente y devuelve su
+// CSS. Cada bloque se reemplaza por sus saltos de línea para no mover las líneas
+// del resto del archivo (el mapa de fuente de componentes es la identidad).
+func extractScopedStyle(source string) (string, string) {
+	var css []string
+	out := scopedStylePattern.ReplaceAllStringFunc(source, func(m string) string {
+		css = append(css, strings.TrimSpace(scopedStylePattern.FindStringSubmatch(m)[1]))
+		return strings.Repeat("\n", strings.Count(m, "\n"))
+	})
+	return out, strings.Join(css, "\n")
+}
+
+// -----------------------------------------------------------------------------
+// Reescritura de selectores
+// -----------------------------------------------------------------------------
+
+// scopeCSS acota cada regla del CSS al atributo dado. Recorre reglas anidadas en
+// @media/@supports/@container/@layer; el resto de las at-rules (@keyframes,
+// @font-face, …) se copian sin cambios.
+func scopeCSS(css, attr string) string {
+	var out strings.Builder
+	sel := "[" + attr + "]"
+	i := 0
+	for i < len(css) {
+		open := indexOutsideCSSStrings(css, i, '{')
+		if open < 0 {
+			out.WriteString(css[i:])
+			break
+		}
+		closeIdx := matchingBrace(css, open)
+		prelude := stripCSSComments(css[i:open])
+		// Sentencias previas sin bloque (@import …;) se copian tal cual.
+		if semi := strings.LastIndexByte(prelude, ';'); semi >= 0 {
+			out.WriteString(strings.TrimSpace(prelude[:semi+1]) + "\n")
+			prelude = prelude[semi+1:]
+		}
+		prelude = strings.TrimSpace(prelude)
+		body := css[open+1 : closeIdx]
+
+		switch {
+		case strings.HasPrefix(prelude, "@media"), strings.HasPrefix(prelude, "@supports"),
+			strings.HasPrefix(prelude, "@container"), strings.HasPrefix(prelude, "@layer"):
+			fmt.Fprintf(&out, "%s {\n%s\n}\n", prelude, scopeCSS(body, attr))
+		case strings.HasPrefix(prelude, "@"):
+			fmt.Fprintf(&out, "%s {%s}\n", prelude, body)
+		default:
+			fmt.Fprintf(&out, "%s {%s}\n", scopeSelectorList(prelude, sel), body)
+		}
+		i = closeIdx + 1
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func scopeSelectorList(list, sel string) string {
+	var scoped []string
+	for _, s := range splitTopLevel(list, ',') {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		// Descendiente de una raíz, o la raíz misma como primer compuesto.
+		first, rest := splitFirstCompound(s)
+		scoped = append(scoped, sel+" "+s, insertBeforePseudo(first, sel)+rest)
+	}
+	return strings.Join(scoped, ", ")
+}
+
+// splitFirstCompound separa el primer selector compuesto del resto («.a > .b» -> «.a», « > .b»).
+func splitFirstCompound(s string) (string, string) {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ' ', '>', '+', '~', '\t', '\n':
+			if depth == 0 {
+				return s[:i], s[i:]
+			}
+		}
+	}
+	return s, ""
+}
+
+// insertBeforePseudo agrega sel antes de pseudo-clases/elementos («a:hover» -> «a[x]:hover»).
+func insertBeforePseudo(compound, sel string) string {
+	depth := 0
+	for i, r := range compound {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				return compound[:i] + sel + compound[i:]
+			}
+		}
+	}
+	return compound + sel
+}
+
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// indexOutsideCSSStrings busca c desde start ignorando strings y comentarios.
+func indexOutsideCSSStrings(css string, start int, c byte) int {
+	for i := start; i < len(css); i++ {
+		switch {
+		case css[i] == '"' || css[i] == '\'':
+			q := css[i]
+			for i++; i < len(css) && css[i] != q; i++ {
+				if css[i] == '\\' {
+					i++
+				}
+			}
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return -1
+			}
+			i += end + 3
+		case css[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+func matchingBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); {
+		next := indexOutsideCSSStrings(css, i, '{')
+		end := indexOutsideCSSStrings(css, i, '}')
+		if end < 0 {
+			return len(css) - 1
+		}
+		if next >= 0 && next < end {
+			depth++
+			i = next + 1
+			continue
+		}
+		depth--
+		if depth == 0 {
+			return end
+		}
+		i = end + 1
+	}
+	return len(css) - 1
+}
+
+func stripCSSComments(s string) string {
+	for {
+		start := strings.Index(s, "/*")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start+2:], "*/")
+		if end < 0 {
+			return s[:start]
+		}
+		s = s[:start] + s[start+2+end+2:]
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Atributo en los elementos raíz
+// -----------------------------------------------------------------------------
+
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {}, "input": {},
+	"link": {}, "meta": {}, "source": {}, "track": {}, "wbr": {},
+}
+
+// addRootAttr agrega attr a los elementos de primer nivel del cuerpo de un
+// componente. Los bloques {{...}} no cuentan como nivel: un elemento dentro de
+// {{if}} en el primer nivel también es raíz.
+func addRootAttr(src, attr string) string {
+	var out strings.Builder
+	depth := 0
+	i := 0
+	for i < len(src) {
+		switch {
+		case strings.HasPrefix(src[i:], "{{"):
+			end := strings.Index(src[i:], "}}")
+			if end < 0 {
+				out.WriteString(src[i:])
+				return out.String()
+			}
+			out.WriteString(src[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(src[i:], "<!--"):
+			end := strings.Index(src[i:], "-->")
+			if end < 0 {
+				out.WriteString(src[i:])
+				return out.String()
+			}
+			out.WriteString(src[i : i+end+3])
+			i += end + 3
+		case strings.HasPrefix(src[i:], "</"):
+			depth--
+			out.WriteString("</")
+			i += 2
+		case src[i] == '<' && i+1 < len(src) && isASCIILetter(src[i+1]):
+			end := tagEnd(src, i)
+			tag := src[i:end]
+			name := strings.ToLower(tagName(tag))
+			selfClosing := strings.HasSuffix(tag, "/>")
+			if depth == 0 {
+				insert := len(tag) - 1
+				if selfClosing {
+					insert--
+				}
+				tag = tag[:insert] + " " + attr + tag[insert:]
+			}
+			_, void := voidElements[name]
+			if !void && !selfClosing {
+				depth++
+			}
+			out.WriteString(tag)
+			i = end
+			// Texto crudo: no buscar tags dentro de <script>/<style>.
+			if name == "script" || name == "style" {
+				closeTag := strings.Index(strings.ToLower(src[i:]), "</"+name)
+				if closeTag < 0 {
+					closeTag = len(src) - i
+				}
+				out.WriteString(src[i : i+closeTag])
+				i += closeTag
+			}
+		default:
+			out.WriteByte(src[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// tagEnd devuelve el índice siguiente al «>» del tag que empieza en start,
+// respetando comillas y bloques {{...}}.
+func tagEnd(src string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(src); i++ {
+		switch {
+		case quote != 0:
+			if src[i] == quote {
+				quote = 0
+			}
+		case src[i] == '"' || src[i] == '\'':
+			quote = src[i]
+		case strings.HasPrefix(src[i:], "{{"):
+			if end := strings.Index(src[i:], "}}"); end >= 0 {
+				i += end + 1
+			}
+		case src[i] == '>':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+func tagName(tag string) string {
+	end := 1
+	for end < len(tag) && (isASCIILetter(tag[end]) || tag[end] == '-' || (tag[end] >= '0' && tag[end] <= '9')) {
+		end++
+	}
+	return tag[1:end]
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
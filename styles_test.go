@@ -0,0 +1,121 @@
+package teggo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScopeCSS(t *testing.T) {
+	css := `/* título */
+@import url("base.css");
+.card h2, a:hover { color: red; content: "}" }
+@media (max-width: 600px) { .card { padding: 0 } }
+@keyframes fade { from { opacity: 0 } to { opacity: 1 } }`
+
+	got := scopeCSS(css, "data-t-x")
+	for _, want := range []string{
+		`@import url("base.css");`,
+		`[data-t-x] .card h2, .card[data-t-x] h2, [data-t-x] a:hover, a[data-t-x]:hover { color: red; content: "}" }`,
+		"@media (max-width: 600px) {\n[data-t-x] .card, .card[data-t-x] { padding: 0 }\n}",
+		`@keyframes fade { from { opacity: 0 } to { opacity: 1 } }`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scoped CSS missing %q\n--- Got ---\n%s", want, got)
+		}
+	}
+}
+
+func TestRender_ScopedStylesOnlyForRenderedComponents(t *testing.T) {
+	files := map[string]string{
+		"components/Card.html": `{{tag Card}}
+<style scoped>
+.card { border: 1px solid; }
+</style>
+{{if .Wide}}<div class="card wide">{{slot}}</div>{{else}}<div class="card"><img src="x.png"><p>{{slot}}</p></div>{{end}}
+{{end}}`,
+		"components/Badge.html": `{{tag Badge}}<style scoped>span { color: red }</style><span>{{slot}}</span>{{end}}`,
+		"pages/Home.html":       `<head>{{styles}}</head><Card>hola</Card><Card>otra</Card><If cond=".ShowBadge"><Badge>1</Badge></If>`,
+	}
+
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]interface{}{}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	got := out.String()
+
+	card, badge := scopeAttr("Card"), scopeAttr("Badge")
+	if strings.Contains(got, "teggo:") {
+		t.Errorf("asset marks leaked into output: %q", got)
+	}
+	if strings.Count(got, "<style>") != 1 || !strings.Contains(got, "["+card+"] .card") {
+		t.Errorf("expected one <style> with Card CSS:\n%s", got)
+	}
+	if strings.Contains(got, badge) {
+		t.Errorf("Badge styles shipped although Badge was not rendered:\n%s", got)
+	}
+	if !strings.Contains(got, `<div class="card" `+card+`><img src="x.png"><p>hola</p></div>`) {
+		t.Errorf("root element not scoped (or nested elements scoped):\n%s", got)
+	}
+	if all := eng.Styles(); !strings.Contains(all, card) || !strings.Contains(all, badge) {
+		t.Errorf("Styles() should include every component:\n%s", all)
+	}
+}
+
+func TestRender_ScriptsAndHeadDeduplicated(t *testing.T) {
+	files := map[string]string{
+		"components/Tabs.html": `{{tag Tabs}}
+<head><link rel="stylesheet" href="/tabs.css"></head>
+<script once src="/ui.js"></script>
+<script once>initTabs()</script>
+<div class="tabs">{{slot}}</div><script>inline({{.N}})</script>
+{{end}}`,
+		"components/Menu.html":  `{{tag Menu}}<script src="/ui.js" once></script><nav>{{slot}}</nav>{{end}}`,
+		"components/Modal.html": `{{tag Modal}}<script once src="/modal.js"></script><dialog>{{slot}}</dialog>{{end}}`,
+		"pages/Home.html": `<head>{{head}}</head>
+<For each=".Items" as="n"><Tabs :N="$n">{{$n}}</Tabs></For><Menu>m</Menu>
+{{scripts}}`,
+	}
+
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]interface{}{"Items": []int{1, 2, 3}}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	got := out.String()
+
+	for want, n := range map[string]int{
+		`<script src="/ui.js"></script>`:           1,
+		`<script>initTabs()</script>`:              1,
+		`<link rel="stylesheet" href="/tabs.css">`: 1,
+		`<script>inline(`:                          3,
+		`/modal.js`:                                0,
+	} {
+		if c := strings.Count(got, want); c != n {
+			t.Errorf("expected %d × %q, got %d:\n%s", n, want, c, got)
+		}
+	}
+	if strings.Index(got, "initTabs") < strings.LastIndex(got, "<dialog") || strings.Index(got, "initTabs") < strings.LastIndex(got, "<nav") {
+		t.Errorf("scripts should be emitted at the {{scripts}} placeholder:\n%s", got)
+	}
+}
+
+func TestCompile_DocumentAssetsRejectActions(t *testing.T) {
+	files := map[string]string{
+		"components/Bad.html": "{{tag Bad}}\n<script once>var id = {{.ID}}</script>\n<p></p>{{end}}",
+	}
+	_, err := NewEngineFromSource(files, false)
+	var ce *CompileError
+	if !errors.As(err, &ce) || ce.Line != 2 {
+		t.Fatalf("expected CompileError at line 2, got %v", err)
+	}
+}
@@ -0,0 +1,486 @@
+// tokenizer.go
+// Paquete teggo — Tokenizador de la sintaxis Teggo para páginas.
+// -----------------------------------------------------------------------------
+// Reemplaza al parser HTML genérico: reconoce solo lo que el transpilador
+// necesita y deja el resto del markup tal cual fue escrito.
+//
+//   - bloques {{...}}, aunque contengan "}}" dentro de strings o comentarios;
+//   - tags de componentes registrados y de control (<Card …>, </Card>, <Card/>),
+//     sensibles a mayúsculas, y <slot>;
+//   - comentarios HTML y elementos de texto crudo (<script>, <style>, <pre>),
+//     donde no se buscan tags Teggo;
+//   - elementos de texto (<title>, <textarea>): adentro no hay tags HTML, pero
+//     sí tags Teggo, cuya salida queda como texto;
+//   - tags HTML normales, cuyos valores entre comillas pueden contener «<Card».
+//
+// El resultado es un árbol de nodos: texto (markup incluido), acciones GoTpl,
+// comentarios y tags Teggo con sus hijos.
+
+package teggo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nodeKind es el tipo de un nodo del árbol de una página.
+type nodeKind int
+
+const (
+	textNode    nodeKind = iota // Texto y markup HTML, tal cual
+	actionNode                  // Bloque {{...}}
+	tagNode                     // Componente, tag de control o <slot>
+	commentNode                 // Comentario HTML sin bloques GoTpl
+)
+
+// node es un nodo del árbol producido por parseTeggo.
+type node struct {
+	Kind        nodeKind
+	Name        string          // tagNode: nombre tal como se escribió
+	Attrs       []componentAttr // tagNode
+	Text        string          // Texto original; en tagNode, el tag de apertura
+	Close       string          // tagNode: tag de cierre original ("" si es <X/>)
+	SelfClosing bool
+	Children    []*node
+	Pos         int // Offset en el original
+}
+
+// rawTextElements son los elementos cuyo contenido no se analiza en busca de tags.
+var rawTextElements = map[string]struct{}{
+	"script": {},
+	"style":  {},
+	"pre":    {},
+}
+
+// rcdataElements son los elementos de solo texto: no contienen tags HTML, pero
+// sí se buscan tags Teggo (<title><Brand/> - Inicio</title>).
+var rcdataElements = map[string]struct{}{
+	"title":    {},
+	"textarea": {},
+}
+
+// tokenizer recorre una página armando el árbol de nodos.
+type tokenizer struct {
+	src   string
+	pos   int
+	isTag func(name string) bool
+	stack []*node // Tags Teggo abiertos; stack[0] es la raíz
+	text  int     // Inicio del texto pendiente
+}
+
+// parseTeggo tokeniza src y devuelve la raíz del árbol (un tagNode sin nombre).
+// isTag indica qué nombres son tags Teggo (componentes y tags de control);
+// <slot> lo es siempre. Los errores son posError ubicados en src.
+func parseTeggo(src string, isTag func(name string) bool) (*node, error) {
+	t := &tokenizer{src: src, isTag: isTag}
+	root := &node{Kind: tagNode}
+	t.stack = []*node{root}
+
+	raw := ""   // Cierre que termina el texto crudo actual ("-->", "</script", …)
+	rcdata := 0 // Dentro de <title>/<textarea>: profundidad de la pila al abrirlo
+	for t.pos < len(src) {
+		rest := src[t.pos:]
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			if err := t.action(); err != nil {
+				return nil, err
+			}
+		case raw != "":
+			switch {
+			case hasPrefixFold(rest, raw):
+				if rcdata > 0 && len(t.stack) > rcdata {
+					open := t.stack[len(t.stack)-1]
+					return nil, &posError{pos: open.Pos, component: open.Name, err: fmt.Errorf("unclosed <%s> inside <%s>", open.Name, raw[2:])}
+				}
+				t.pos += len(raw)
+				raw, rcdata = "", 0
+			case rcdata > 0 && strings.HasPrefix(rest, "</") && t.teggoTag(readTagName(rest[2:])):
+				name := readTagName(rest[2:])
+				if len(t.stack) <= rcdata {
+					return nil, &posError{pos: t.pos, component: name, err: fmt.Errorf("unexpected </%s> inside <%s>", name, raw[2:])}
+				}
+				if err := t.closeTag(name); err != nil {
+					return nil, err
+				}
+			case rcdata > 0 && strings.HasPrefix(rest, "<") && t.teggoTag(readTagName(rest[1:])):
+				if err := t.openTag(readTagName(rest[1:])); err != nil {
+					return nil, err
+				}
+			default:
+				t.pos++
+			}
+		case strings.HasPrefix(rest, "<!--"):
+			// Un comentario con bloques {{...}} queda como texto (html/template lo omite).
+			if end := strings.Index(rest, "-->"); end >= 0 && !strings.Contains(rest[:end], "{{") {
+				t.flushText(t.pos)
+				t.add(&node{Kind: commentNode, Text: rest[:end+len("-->")], Pos: t.pos})
+				t.pos += end + len("-->")
+				t.text = t.pos
+				continue
+			}
+			t.pos += len("<!--")
+			raw = "-->"
+		case strings.HasPrefix(rest, "</"):
+			name := readTagName(rest[2:])
+			if name == "" || !t.teggoTag(name) {
+				t.pos += 2 + len(name)
+				continue
+			}
+			if err := t.closeTag(name); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(rest, "<"):
+			name := readTagName(rest[1:])
+			switch {
+			case name == "":
+				t.pos++
+			case t.teggoTag(name):
+				if err := t.openTag(name); err != nil {
+					return nil, err
+				}
+			default:
+				selfClosing, err := t.htmlTag(name)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := rawTextElements[strings.ToLower(name)]; ok && !selfClosing {
+					raw = "</" + name
+				}
+				if _, ok := rcdataElements[strings.ToLower(name)]; ok && !selfClosing {
+					raw, rcdata = "</"+name, len(t.stack)
+				}
+			}
+		default:
+			t.pos++
+		}
+	}
+	t.flushText(len(src))
+
+	if open := t.stack[len(t.stack)-1]; open != root {
+		return nil, &posError{pos: open.Pos, component: open.Name, err: fmt.Errorf("unclosed <%s>", open.Name)}
+	}
+	return root, nil
+}
+
+func (t *tokenizer) teggoTag(name string) bool {
+	return name == "slot" || t.isTag(name)
+}
+
+// add agrega un nodo al tag abierto actual.
+func (t *tokenizer) add(n *node) {
+	parent := t.stack[len(t.stack)-1]
+	parent.Children = append(parent.Children, n)
+}
+
+// flushText agrega el texto pendiente hasta end.
+func (t *tokenizer) flushText(end int) {
+	if end > t.text {
+		t.add(&node{Kind: textNode, Text: t.src[t.text:end], Pos: t.text})
+	}
+	t.text = end
+}
+
+// action consume un bloque {{...}} en la posición actual.
+func (t *tokenizer) action() error {
+	end := actionEnd(t.src, t.pos)
+	if end < 0 {
+		return &posError{pos: t.pos, err: fmt.Errorf("unclosed action")}
+	}
+	t.flushText(t.pos)
+	t.add(&node{Kind: actionNode, Text: t.src[t.pos:end], Pos: t.pos})
+	t.pos, t.text = end, end
+	return nil
+}
+
+// openTag consume un tag de apertura Teggo: <Name attrs> o <Name attrs/>.
+func (t *tokenizer) openTag(name string) error {
+	start := t.pos
+	attrs, end, selfClosing, err := lexAttrs(t.src, start+1+len(name))
+	if err != nil {
+		return &posError{pos: start, component: name, err: err}
+	}
+	t.flushText(start)
+	n := &node{Kind: tagNode, Name: name, Attrs: attrs, Text: t.src[start:end], SelfClosing: selfClosing, Pos: start}
+	t.add(n)
+	if !selfClosing {
+		t.stack = append(t.stack, n)
+	}
+	t.pos, t.text = end, end
+	return nil
+}
+
+// closeTag consume </Name>, que debe cerrar el último tag Teggo abierto.
+func (t *tokenizer) closeTag(name string) error {
+	start := t.pos
+	end := strings.IndexByte(t.src[start:], '>')
+	if end < 0 || strings.TrimSpace(t.src[start+2+len(name):start+end]) != "" {
+		return &posError{pos: start, component: name, err: fmt.Errorf("malformed </%s>", name)}
+	}
+	end += start + 1
+
+	open := t.stack[len(t.stack)-1]
+	if len(t.stack) == 1 {
+		return &posError{pos: start, component: name, err: fmt.Errorf("unexpected </%s>", name)}
+	}
+	if open.Name != name {
+		return &posError{pos: start, component: name, err: fmt.Errorf("</%s> does not match <%s> at offset %d", name, open.Name, open.Pos)}
+	}
+	t.flushText(start)
+	open.Close = t.src[start:end]
+	t.stack = t.stack[:len(t.stack)-1]
+	t.pos, t.text = end, end
+	return nil
+}
+
+// htmlTag saltea un tag HTML normal, que queda como texto. Los bloques {{...}}
+// de sus atributos se vuelven acciones; dentro de comillas no se buscan tags.
+func (t *tokenizer) htmlTag(name string) (selfClosing bool, err error) {
+	start := t.pos
+	t.pos += 1 + len(name)
+	var quote byte
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case strings.HasPrefix(t.src[t.pos:], "{{"):
+			if err := t.action(); err != nil {
+				return false, err
+			}
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			t.pos++
+			return t.src[t.pos-2] == '/', nil
+		}
+		t.pos++
+	}
+	return false, &posError{pos: start, err: fmt.Errorf("unterminated <%s> tag", name)}
+}
+
+// lexAttrs lee los atributos de un tag Teggo desde i hasta el cierre (> o />).
+// Además de los literales reconoce expresiones:
+//
+//	{...$user}           -> spread de $user
+//	:Count=".Total"      -> "Count" (.Total)
+//	Count={{len .Items}} -> "Count" (len .Items)
+//	Title="Hola {{.X}}"  -> "Title" (printf "Hola %v" (.X)) (ver attrPipeline)
+//	disabled             -> "disabled" true
+func lexAttrs(src string, i int) (attrs []componentAttr, end int, selfClosing bool, err error) {
+	for {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		switch {
+		case i >= len(src):
+			return nil, 0, false, fmt.Errorf("unterminated tag")
+		case src[i] == '>':
+			return attrs, i + 1, false, nil
+		case strings.HasPrefix(src[i:], "/>"):
+			return attrs, i + 2, true, nil
+		case strings.HasPrefix(src[i:], "{..."):
+			close := strings.IndexByte(src[i:], '}')
+			if close < 0 {
+				return nil, 0, false, fmt.Errorf("unterminated spread attribute")
+			}
+			expr := strings.TrimSpace(src[i+len("{...") : i+close])
+			if expr == "" {
+				return nil, 0, false, fmt.Errorf("empty spread attribute")
+			}
+			attrs = append(attrs, componentAttr{Key: expr, Spread: true})
+			i += close + 1
+			continue
+		}
+
+		// Nombre
+		start := i
+		for i < len(src) && !isSpace(src[i]) && !strings.ContainsRune(`"'>/=`, rune(src[i])) {
+			i++
+		}
+		key := src[start:i]
+		if key == "" {
+			return nil, 0, false, fmt.Errorf("unexpected %q in tag", src[i])
+		}
+
+		// Valor (opcional)
+		j := i
+		for j < len(src) && isSpace(src[j]) {
+			j++
+		}
+		if j >= len(src) || src[j] != '=' {
+			// Atributo sin valor: booleano
+			attrs = append(attrs, componentAttr{Key: key, Val: "true", Expr: true})
+			continue
+		}
+		for j++; j < len(src) && isSpace(src[j]); j++ {
+		}
+		val, next, kind, err := lexAttrValue(src, j)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("attribute %s: %w", key, err)
+		}
+		i = next
+
+		switch {
+		case strings.HasPrefix(key, ":") && len(key) > 1:
+			attrs = append(attrs, componentAttr{Key: key[1:], Val: val, Expr: true})
+		case kind == '{':
+			attrs = append(attrs, componentAttr{Key: key, Val: mustacheInner(val), Expr: true})
+		case strings.Contains(val, "{{"):
+			pipeline, err := attrPipeline(val)
+			if err != nil {
+				return nil, 0, false, fmt.Errorf("attribute %s: %w", key, err)
+			}
+			attrs = append(attrs, componentAttr{Key: key, Val: pipeline, Expr: true})
+		default:
+			attrs = append(attrs, componentAttr{Key: key, Val: val})
+		}
+	}
+}
+
+// lexAttrValue lee un valor de atributo en i: "…", '…', {{…}} o sin comillas.
+// kind es la comilla, '{' para un bloque GoTpl o 0 si no tiene comillas.
+func lexAttrValue(src string, i int) (val string, end int, kind byte, err error) {
+	if i >= len(src) {
+		return "", 0, 0, fmt.Errorf("missing value")
+	}
+	switch c := src[i]; {
+	case c == '"' || c == '\'':
+		for j := i + 1; j < len(src); j++ {
+			if strings.HasPrefix(src[j:], "{{") {
+				// Las comillas dentro de un bloque no cierran el valor.
+				if e := actionEnd(src, j); e > 0 {
+					j = e - 1
+					continue
+				}
+			}
+			if src[j] == c {
+				return src[i+1 : j], j + 1, c, nil
+			}
+		}
+		return "", 0, 0, fmt.Errorf("unterminated value")
+	case strings.HasPrefix(src[i:], "{{"):
+		e := actionEnd(src, i)
+		if e < 0 {
+			return "", 0, 0, fmt.Errorf("unclosed action")
+		}
+		return src[i:e], e, '{', nil
+	default:
+		j := i
+		for j < len(src) && !isSpace(src[j]) && src[j] != '>' && !strings.HasPrefix(src[j:], "/>") {
+			j++
+		}
+		if j == i {
+			return "", 0, 0, fmt.Errorf("missing value")
+		}
+		return src[i:j], j, 0, nil
+	}
+}
+
+// controlActionPattern reconoce acciones que no son pipelines ({{if}}, {{end}}, …).
+var controlActionPattern = regexp.MustCompile(`^(if|else|end|range|with|define|block|template|break|continue)\b`)
+
+// attrPipeline convierte un valor de atributo con bloques {{...}} en un pipeline:
+//
+//	"{{.User.Name}}"   -> .User.Name (conserva el tipo del valor)
+//	"Hola {{.Name}}!"  -> printf "Hola %v!" (.Name)
+//
+// Los comentarios {{/* */}} se descartan; las acciones de control no se admiten.
+func attrPipeline(val string) (string, error) {
+	var format, literal strings.Builder
+	var args []string
+	for i := 0; i < len(val); {
+		start := strings.Index(val[i:], "{{")
+		if start < 0 {
+			start = len(val)
+		} else {
+			start += i
+		}
+		format.WriteString(strings.ReplaceAll(val[i:start], "%", "%%"))
+		literal.WriteString(val[i:start])
+		if start == len(val) {
+			break
+		}
+		end := actionEnd(val, start)
+		if end < 0 {
+			return "", fmt.Errorf("unclosed action")
+		}
+		inner := mustacheInner(val[start:end])
+		i = end
+		switch {
+		case strings.HasPrefix(inner, "/*"):
+			continue
+		case inner == "" || controlActionPattern.MatchString(inner):
+			return "", fmt.Errorf("%s is not allowed in a component attribute; use a pipeline", val[start:end])
+		}
+		format.WriteString("%v")
+		args = append(args, "("+inner+")")
+	}
+	switch {
+	case len(args) == 0:
+		return strconv.Quote(literal.String()), nil
+	case len(args) == 1 && format.String() == "%v":
+		return strings.TrimSuffix(strings.TrimPrefix(args[0], "("), ")"), nil
+	}
+	return fmt.Sprintf("printf %s %s", strconv.Quote(format.String()), strings.Join(args, " ")), nil
+}
+
+// actionEnd devuelve el offset siguiente al cierre del bloque {{...}} que
+// empieza en i, o -1. Saltea strings, caracteres y comentarios GoTpl.
+func actionEnd(src string, i int) int {
+	for j := i + 2; j < len(src); j++ {
+		switch c := src[j]; {
+		case strings.HasPrefix(src[j:], "}}"):
+			return j + 2
+		case strings.HasPrefix(src[j:], "/*"):
+			k := strings.Index(src[j+2:], "*/")
+			if k < 0 {
+				return -1
+			}
+			j += 2 + k + 1
+		case c == '`':
+			k := strings.IndexByte(src[j+1:], '`')
+			if k < 0 {
+				return -1
+			}
+			j += 1 + k
+		case c == '"' || c == '\'':
+			for j++; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' {
+					j++
+				} else if src[j] == '\n' {
+					return -1
+				}
+			}
+			if j >= len(src) {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// readTagName lee un nombre de tag al comienzo de s ("" si no empieza con letra).
+func readTagName(s string) string {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if !isASCIILetter(c) && (i == 0 || !(c >= '0' && c <= '9' || c == '-' || c == '_' || c == ':' || c == '.')) {
+			break
+		}
+		i++
+	}
+	return s[:i]
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
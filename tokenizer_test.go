@@ -0,0 +1,101 @@
+package teggo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// dumpTree resume el árbol: tags como Name[...], acciones como {...} y texto tal cual.
+func dumpTree(n *node) string {
+	var sb strings.Builder
+	for _, c := range n.Children {
+		switch c.Kind {
+		case textNode, commentNode:
+			sb.WriteString(c.Text)
+		case actionNode:
+			sb.WriteString("{" + mustacheInner(c.Text) + "}")
+		case tagNode:
+			sb.WriteString(c.Name)
+			if c.SelfClosing {
+				sb.WriteString("/")
+				continue
+			}
+			sb.WriteString("[" + dumpTree(c) + "]")
+		}
+	}
+	return sb.String()
+}
+
+func TestParseTeggo_Tree(t *testing.T) {
+	isTag := func(name string) bool { return name == "Card" || name == "Button" || isControlTag(name) }
+	cases := []struct{ src, want string }{
+		{`<Card Title="x"><b>hi</b> <Button/></Card>`, `Card[<b>hi</b> Button/]`},
+		{`<Card><slot name="Footer">f</slot></Card>`, `Card[slot[f]]`},
+		{`<!-- <Card> --><pre><Card></pre><style>Card{}</style>`, `<!-- <Card> --><pre><Card></pre><style>Card{}</style>`},
+		{`<a title="<Card>" href="/u/{{.ID}}">x</a>`, `<a title="<Card>" href="/u/{.ID}">x</a>`},
+		{`<card></card><CardList></CardList>`, `<card></card><CardList></CardList>`},
+		{`{{/* }} */}}{{printf "}}"}}<If cond=".X">a<Else>b</Else></If>`, `{/* }} */}{printf "}}"}If[aElse[b]]`},
+		{`<Card :Title='index .M "a>b"' {...$u} disabled Count={{len .Items}}/>`, `Card/`},
+		{`<title><Card>x</Card> <b>{{.T}}</title><textarea><Button/></textarea>`, `<title>Card[x] <b>{.T}</title><textarea>Button/</textarea>`},
+	}
+	for _, tc := range cases {
+		root, err := parseTeggo(tc.src, isTag)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if got := dumpTree(root); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.src, got, tc.want)
+		}
+	}
+
+	root, err := parseTeggo(`<Card :Title='index .M "a>b"' {...$u} disabled Count={{len .Items}} Class="c"/>`, isTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []componentAttr{
+		{Key: "Title", Val: `index .M "a>b"`, Expr: true},
+		{Key: "$u", Spread: true},
+		{Key: "disabled", Val: "true", Expr: true},
+		{Key: "Count", Val: "len .Items", Expr: true},
+		{Key: "Class", Val: "c"},
+	}
+	if attrs := root.Children[0].Attrs; len(attrs) != len(want) {
+		t.Fatalf("attrs: %+v", attrs)
+	} else {
+		for i := range want {
+			if attrs[i] != want[i] {
+				t.Errorf("attr %d = %+v, want %+v", i, attrs[i], want[i])
+			}
+		}
+	}
+}
+
+func TestParseTeggo_Errors(t *testing.T) {
+	isTag := func(name string) bool { return name == "Card" || name == "Button" }
+	cases := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{`<p><Card>x</p>`, 3, "unclosed <Card>"},
+		{`<Card><Button></Card></Button>`, 14, "</Card> does not match <Button>"},
+		{`x</Card>`, 1, "unexpected </Card>"},
+		{`<Card Title="x>`, 0, "unterminated value"},
+		{`<b>{{.X</b>`, 3, "unclosed action"},
+		{`<title><Card>x</title></Card>`, 7, "unclosed <Card> inside <title>"},
+		{`<Card><title></Card></title>`, 13, "unexpected </Card> inside <title>"},
+	}
+	for _, tc := range cases {
+		_, err := parseTeggo(tc.src, isTag)
+		var pe *posError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: expected posError, got %v", tc.src, err)
+			continue
+		}
+		if pe.pos != tc.pos || !strings.Contains(pe.Error(), tc.msg) {
+			t.Errorf("%s: got %d %q, want %d %q", tc.src, pe.pos, pe.Error(), tc.pos, tc.msg)
+		}
+	}
+}
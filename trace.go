@@ -0,0 +1,131 @@
+// trace.go
+// Paquete teggo — Pila de llamadas a componentes en los errores de render.
+// -----------------------------------------------------------------------------
+// html/template solo nombra el template más interno que falló. Cada tag de
+// componente se ejecuta con {{component N "Card" …}}, donde N es el id de la
+// llamada: si falla, su error se envuelve en un frameError con ese id, así la
+// pila se arma recién al fallar, recorriendo la cadena de errores, sin marcas
+// en la salida. Los layouts se ejecutan en línea y encabezan la pila de su
+// página. Cada id se traduce a la posición del tag en el archivo original.
+
+package teggo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// callSite es una llamada a componente registrada por el walker.
+type callSite struct {
+	Page      string
+	Component string
+	Pos       int // Offset del tag en el original
+}
+
+// addSite registra una llamada a componente y devuelve su id.
+func (p *Parser) addSite(page, component string, pos int) int {
+	p.sites = append(p.sites, callSite{Page: page, Component: component, Pos: pos})
+	return len(p.sites) - 1
+}
+
+// layoutSites devuelve las llamadas a los layouts de page, de afuera hacia
+// adentro: la página ejecuta su layout, que a su vez puede ejecutar otro.
+func (p *Parser) layoutSites(page string) []int {
+	var sites []int
+	for ref, ok := p.layouts[page]; ok && len(sites) <= len(p.layouts); ref, ok = p.layouts[ref.Name] {
+		sites = append(sites, ref.Site)
+	}
+	return sites
+}
+
+// Frame es una llamada a componente en la pila de un RenderError.
+type Frame struct {
+	Component string // Componente invocado.
+	Template  string // Template lógico donde está el tag.
+	File      string
+	Line      int
+	Column    int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("<%s> at %s:%d:%d", f.Component, f.File, f.Line, f.Column)
+}
+
+// RenderError es un error de render con la pila de componentes, de afuera
+// hacia adentro: página -> Card -> UserCard -> MyButton.
+type RenderError struct {
+	Template string
+	Stack    []Frame
+	Err      error
+}
+
+func (e *RenderError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "teggo: rendering %s", e.Template)
+	for _, f := range e.Stack {
+		sb.WriteString(" > ")
+		sb.WriteString(f.String())
+	}
+	fmt.Fprintf(&sb, ": %v", e.Err)
+	return sb.String()
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// frameError es el fallo de una llamada a componente (site); el mensaje es el
+// del error original.
+type frameError struct {
+	site int
+	err  error
+}
+
+func (e *frameError) Error() string { return e.err.Error() }
+func (e *frameError) Unwrap() error { return e.err }
+
+// edgePattern son los espacios y marcas en los bordes de una salida.
+var edgePattern = regexp.MustCompile(`^(?:\s|` + assetMark + `[^` + markDelim + `]*` + assetMark + `)+|(?:\s|` + assetMark + `[^` + markDelim + `]*` + assetMark + `)+$`)
+
+// trimOutput es strings.TrimSpace sin que las marcas de los bordes (uso de
+// componentes, flush) frenen el recorte; las marcas se conservan.
+func trimOutput(s string) string {
+	if !strings.Contains(s, assetMark) {
+		return strings.TrimSpace(s)
+	}
+	return edgePattern.ReplaceAllStringFunc(s, func(edge string) string {
+		return strings.Join(assetMarkPattern.FindAllString(edge, -1), "")
+	})
+}
+
+// renderError arma el RenderError de name: los layouts de la página y las
+// llamadas de los frameError de la cadena, de afuera hacia adentro.
+func (e *Engine) renderError(name string, err error) error {
+	var re *RenderError
+	if errors.As(err, &re) {
+		return err
+	}
+	sites := e.parser.layoutSites(name)
+	for x := err; x != nil; x = errors.Unwrap(x) {
+		if fe, ok := x.(*frameError); ok {
+			sites = append(sites, fe.site)
+		}
+	}
+	re = &RenderError{Template: name, Err: err}
+	for _, id := range sites {
+		if id >= 0 && id < len(e.frames) {
+			re.Stack = append(re.Stack, e.frames[id])
+		}
+	}
+	e.notify(re)
+	return re
+}
+
+// notify pasa al ErrorHook el fallo de un render que no vino de un componente
+// (esos ya pasaron por report): un error de la página o de su layout.
+func (e *Engine) notify(re *RenderError) {
+	if e.opts.OnError == nil || reported(re.Err) {
+		return
+	}
+	e.opts.OnError(re.Template, re.Err)
+}
@@ -0,0 +1,140 @@
+package teggo
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRender_ErrorCarriesComponentStack(t *testing.T) {
+	page := `<h1>Users</h1>
+<Card Title="Team">
+  <For each=".Users" as="user">
+    <UserCard :Name="$user">
+      <MyButton Label="Edit"></MyButton>
+    </UserCard>
+  </For>
+</Card>`
+	base := map[string]string{
+		"components/Card.html":     `{{tag Card}}<div class="card">{{slot}}</div>{{end}}`,
+		"components/UserCard.html": `{{tag UserCard}}<div>{{.Name}} {{slot}}</div>{{end}}`,
+		"components/MyButton.html": `{{tag MyButton}}<button>{{.Label}} {{index .Items 3}}</button>{{end}}`,
+		"pages/Home.html":          page,
+	}
+	// Con assets la salida pasa por un buffer; sin ellos, va directo al writer.
+	withAssets := map[string]string{
+		"components/Card.html": `{{tag Card}}<style scoped>.card { color: red }</style><div class="card">{{slot}}</div>{{end}}`,
+	}
+	for k, v := range base {
+		if _, ok := withAssets[k]; !ok {
+			withAssets[k] = v
+		}
+	}
+
+	renders := map[string]func(*Engine, io.Writer) error{
+		"Render": func(e *Engine, w io.Writer) error {
+			return e.Render("pages.Home", map[string]interface{}{"Users": []string{"Ana"}}, w)
+		},
+		"RenderStream": func(e *Engine, w io.Writer) error {
+			return e.RenderStream("pages.Home", map[string]interface{}{"Users": []string{"Ana"}}, w)
+		},
+	}
+	for label, files := range map[string]map[string]string{"plain": base, "assets": withAssets} {
+		eng, err := New(WithFiles(files), WithErrorPolicy(PolicyStrict))
+		if err != nil {
+			t.Fatalf("%s: Engine failed to parse generated templates: %v", label, err)
+		}
+		for mode, render := range renders {
+			err := render(eng, io.Discard)
+			var re *RenderError
+			if !errors.As(err, &re) {
+				t.Fatalf("%s/%s: expected *RenderError, got %T: %v", label, mode, err, err)
+			}
+			want := []Frame{
+				{Component: "Card", Template: "pages.Home", File: "pages/Home.html", Line: 2, Column: 1},
+				{Component: "UserCard", Template: "pages.Home", File: "pages/Home.html", Line: 4, Column: 5},
+				{Component: "MyButton", Template: "pages.Home", File: "pages/Home.html", Line: 5, Column: 7},
+			}
+			if len(re.Stack) != len(want) {
+				t.Fatalf("%s/%s: unexpected stack: %v", label, mode, re.Stack)
+			}
+			for i := range want {
+				if re.Stack[i] != want[i] {
+					t.Errorf("%s/%s: frame %d = %+v, want %+v", label, mode, i, re.Stack[i], want[i])
+				}
+			}
+			if msg := re.Error(); !strings.Contains(msg, "<Card> at pages/Home.html:2:1 > <UserCard>") || !strings.Contains(msg, "index") {
+				t.Errorf("%s/%s: unexpected message: %s", label, mode, msg)
+			}
+		}
+	}
+
+	// Sin error, las marcas de la pila no llegan a la salida.
+	eng, _ := NewEngineFromSource(base, false)
+	var out strings.Builder
+	if err := eng.Render("pages.Home", map[string]interface{}{"Users": []string{}}, &out); err != nil {
+		t.Fatalf("Engine failed to render: %v", err)
+	}
+	if strings.Contains(out.String(), "teggo:") {
+		t.Errorf("marks leaked into output: %q", out.String())
+	}
+}
+
+func TestRender_ComponentsInTitleAndAttributes(t *testing.T) {
+	files := map[string]string{
+		"components/Brand.html": `{{tag Brand}}<script once src="/brand.js"></script>Acme{{end}}`,
+		"layouts/Main.html":     `<title>{{slot name="Title"}}</title><div data-x="{{slot name="Title"}}">{{slot}}</div>{{scripts}}`,
+		"pages/Home.html": `{{layout "layouts.Main"}}
+<slot name="Title"><Brand/> - Home</slot>
+<p>hola</p>`,
+	}
+	eng, err := NewEngineFromSource(files, false)
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	renders := map[string]func(io.Writer) error{
+		"Render":       func(w io.Writer) error { return eng.Render("pages.Home", nil, w) },
+		"RenderStream": func(w io.Writer) error { return eng.RenderStream("pages.Home", nil, w) },
+	}
+	for mode, render := range renders {
+		var out strings.Builder
+		if err := render(&out); err != nil {
+			t.Fatalf("%s failed: %v", mode, err)
+		}
+		got := out.String()
+		if strings.Contains(got, "teggo:") || strings.ContainsRune(got, '�') {
+			t.Errorf("%s: marks leaked into output: %q", mode, got)
+		}
+		for _, want := range []string{`<title>Acme - Home</title>`, `data-x="Acme - Home"`, `<script src="/brand.js"></script>`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: output should contain %s: %q", mode, want, got)
+			}
+		}
+	}
+}
+
+func TestRender_ErrorStackStartsWithLayouts(t *testing.T) {
+	files := map[string]string{
+		"layouts/Base.html":    `<body>{{slot}}</body>`,
+		"layouts/Main.html":    `{{layout "layouts.Base"}}<main>{{slot}}</main>`,
+		"components/Card.html": `{{tag Card}}<div>{{index .Items 3}}</div>{{end}}`,
+		"pages/Home.html":      "{{layout \"layouts.Main\"}}\n<Card/>",
+	}
+	eng, err := New(WithFiles(files), WithErrorPolicy(PolicyStrict))
+	if err != nil {
+		t.Fatalf("Engine failed to parse generated templates: %v", err)
+	}
+	var re *RenderError
+	if err := eng.Render("pages.Home", nil, io.Discard); !errors.As(err, &re) {
+		t.Fatalf("expected *RenderError, got %T: %v", err, err)
+	}
+	var got []string
+	for _, f := range re.Stack {
+		got = append(got, f.String())
+	}
+	want := []string{"<layouts.Main> at pages/Home.html:1:1", "<layouts.Base> at layouts/Main.html:1:1", "<Card> at pages/Home.html:2:1"}
+	if strings.Join(got, " > ") != strings.Join(want, " > ") {
+		t.Errorf("stack = %q, want %q", got, want)
+	}
+}